@@ -4,229 +4,2198 @@ import (
 	"bytes"
 	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
+	"math/rand/v2"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"cdnmanager/pkg/checksum"
 	"cdnmanager/pkg/config"
 	"cdnmanager/pkg/database"
+	"cdnmanager/pkg/filescan"
+	"cdnmanager/pkg/linkcheck"
 	"cdnmanager/pkg/models"
 	"cdnmanager/pkg/reconcile"
 	"cdnmanager/pkg/session"
+	"cdnmanager/pkg/validation"
 
+	"github.com/google/uuid"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 const bulkInsertTemplateName = "CDN Manager Bulk Insert Template.csv"
 const databaseExportName = "CDN Manager Records Export.csv"
+const checksumReportName = "CDN Manager Checksum Report.csv"
+
+const checksumWorkers = 4
+
+const (
+	outboxInterval    = 30 * time.Second
+	outboxBaseBackoff = 5 * time.Second
+	outboxMaxBackoff  = 10 * time.Minute
+)
+
+const outboxChangedEvent = "outbox:changed"
+
+// autoSyncIdleCheck is how often the scheduler rereads the config while
+// auto-sync is turned off.
+const autoSyncIdleCheck = time.Minute
+
+const syncChangedEvent = "sync:changed"
+
+const syncBlockedEvent = "sync:blocked"
 
 type App struct {
-	ctx               context.Context
-	db                *database.Database
-	configPath        string
+	ctx        context.Context
+	db         *database.Database
+	configPath string
+	appDir     string
+	schema     []byte
+
+	// sessionLock guards cloudflareSession; bindings take the session once
+	// through ensureSession and use that value throughout
+	sessionLock       sync.Mutex
 	cloudflareSession *session.CloudflareSession
+
+	// backgroundLock keeps outbox flushes and intent recovery from running
+	// while the profile switches
+	backgroundLock sync.Mutex
+	outboxWake     chan struct{}
+
+	// mutationLock is held for reading by user mutations and for writing by
+	// syncs and intent recovery, so neither acts on a change still in flight.
+	// Take it before backgroundLock
+	mutationLock sync.RWMutex
+
+	autoSyncLock   sync.Mutex
+	autoSyncStatus AutoSyncStatus
+
+	// blockedSync is the last sync held back by the delete guard, waiting for
+	// ConfirmSync
+	blockedLock sync.Mutex
+	blockedSync *reconcile.MassDeletionError
+}
+
+func NewApp(db *database.Database, configPath string, appDir string, schema []byte) *App {
+	return &App{
+		db:         db,
+		configPath: configPath,
+		appDir:     appDir,
+		schema:     schema,
+		outboxWake: make(chan struct{}, 1),
+	}
+}
+
+func (a *App) startup(ctx context.Context) {
+	a.ctx = ctx
+	go a.recoverIntents()
+	go a.runOutbox(ctx)
+	go a.runAutoSync(ctx)
+}
+
+// trackMutation marks a user mutation as in flight until the returned function
+// is called. Only exported bindings call it; a read lock taken twice by one
+// goroutine deadlocks once a sync is waiting, so bindings share unlocked
+// helpers instead of calling each other.
+func (a *App) trackMutation() func() {
+	a.mutationLock.RLock()
+	return a.mutationLock.RUnlock
+}
+
+func (a *App) ShowAlert(message string) {
+	runtime.MessageDialog(a.ctx, runtime.MessageDialogOptions{
+		Title:   "Alert",
+		Message: message,
+		Type:    runtime.InfoDialog,
+	})
+}
+
+// -----------------------------------------------------------------------------
+// Config
+// -----------------------------------------------------------------------------
+
+func (a *App) HasConfig() bool {
+	cfg, err := config.LoadConfig(a.configPath)
+	if err != nil {
+		return false
+	}
+	return cfg.IsComplete()
+}
+
+func (a *App) IsConfigured() bool {
+	return a.HasConfig()
+}
+
+func (a *App) GetDomain() (string, error) {
+	cfg, err := config.LoadConfig(a.configPath)
+	if err != nil {
+		return "", fmt.Errorf("load config: %w", err)
+	}
+	return cfg.Domain, nil
+}
+
+func (a *App) SaveConfig(cfg config.Config) error {
+	return config.SaveConfig(a.configPath, cfg)
+}
+
+// warnPlaintextToken logs a config write that could only keep the api token in
+// plaintext. The config itself was saved, so that is not treated as failure.
+func warnPlaintextToken(err error) error {
+	if errors.Is(err, config.ErrPlaintextToken) {
+		fmt.Printf("Warning: %v\n", err)
+		return nil
+	}
+	return err
+}
+
+// GetConfig returns the active profile's settings with the api token redacted.
+func (a *App) GetConfig() (config.Config, error) {
+	cfg, err := config.LoadConfig(a.configPath)
+	if err != nil {
+		return config.Config{}, fmt.Errorf("load config: %w", err)
+	}
+	return cfg.Redacted(), nil
+}
+
+type ConfigUpdateResult struct {
+	SessionRecreated bool `json:"session_recreated"`
+	Resynced         bool `json:"resynced"`
+}
+
+// UpdateConfig changes only the fields set in patch. The Cloudflare session is
+// rebuilt only when a value it holds changes, and the local database is
+// resynced only when the namespace or the sync prefix changes.
+func (a *App) UpdateConfig(patch config.Patch) (ConfigUpdateResult, error) {
+	var result ConfigUpdateResult
+
+	if patch.IsEmpty() {
+		return result, nil
+	}
+
+	// bindings holding the old session finish first, and none start until the
+	// new one is in place
+	a.mutationLock.Lock()
+	defer a.mutationLock.Unlock()
+
+	current, err := config.LoadConfig(a.configPath)
+	if err != nil {
+		return result, fmt.Errorf("load config: %w", err)
+	}
+
+	// a token echoed back from GetConfig is the redacted form, not a new token
+	if patch.CloudflareAPIToken != nil && *patch.CloudflareAPIToken == current.Redacted().CloudflareAPIToken {
+		patch.CloudflareAPIToken = nil
+	}
+
+	if patch.CloudflareAPIToken != nil && *patch.CloudflareAPIToken != current.CloudflareAPIToken && current.TokenFromEnv() {
+		return result, fmt.Errorf("api token is read from %s; change the variable instead", current.CloudflareAPITokenRef)
+	}
+
+	updated := patch.Apply(*current)
+	if err := updated.Validate(); err != nil {
+		return result, err
+	}
+
+	if overridden := config.EnvConflicts(*current, updated); len(overridden) > 0 {
+		return result, fmt.Errorf("cannot change fields overridden by the environment: %s", strings.Join(overridden, ", "))
+	}
+
+	credentialsChanged := updated.CredentialsChanged(*current)
+	sessionChanged := updated.SessionChanged(*current)
+	prefixChanged := updated.SyncPrefix != current.SyncPrefix
+	namespaceChanged := updated.AccountID != current.AccountID || updated.NamespaceID != current.NamespaceID
+
+	if credentialsChanged {
+		if err := session.VerifyCredentials(updated); err != nil {
+			return result, fmt.Errorf("verify credentials: %w", err)
+		}
+	}
+
+	if err := warnPlaintextToken(a.SaveConfig(updated)); err != nil {
+		return result, fmt.Errorf("save config: %w", err)
+	}
+
+	if sessionChanged {
+		// a plan held back for the old namespace or prefix no longer applies
+		a.setBlockedSync(nil)
+		a.resetSession()
+		if _, err := a.ensureSession(); err != nil {
+			return result, err
+		}
+		result.SessionRecreated = true
+	}
+
+	if namespaceChanged || prefixChanged {
+		if err := a.syncActive(nil); err != nil {
+			return result, fmt.Errorf("config saved but sync failed: %w", err)
+		}
+		result.Resynced = true
+	}
+
+	return result, nil
+}
+
+// -----------------------------------------------------------------------------
+// Profiles
+// -----------------------------------------------------------------------------
+
+type ProfileInfo struct {
+	Name     string `json:"name"`
+	Active   bool   `json:"active"`
+	Domain   string `json:"domain"`
+	Complete bool   `json:"complete"`
+}
+
+func (a *App) ListProfiles() ([]ProfileInfo, error) {
+	names, active, err := config.ListProfiles(a.configPath)
+	if err != nil {
+		return nil, fmt.Errorf("list profiles: %w", err)
+	}
+
+	profiles := make([]ProfileInfo, 0, len(names))
+	for _, name := range names {
+		cfg, err := config.LoadProfile(a.configPath, name)
+		if err != nil {
+			return nil, fmt.Errorf("load profile %q: %w", name, err)
+		}
+
+		profiles = append(profiles, ProfileInfo{
+			Name:     name,
+			Active:   name == active,
+			Domain:   cfg.Domain,
+			Complete: cfg.IsComplete(),
+		})
+	}
+
+	return profiles, nil
+}
+
+func (a *App) SwitchProfile(name string) error {
+	previous, err := config.ActiveProfile(a.configPath)
+	if err != nil {
+		return fmt.Errorf("resolve active profile: %w", err)
+	}
+	if name == previous {
+		return nil
+	}
+
+	// no sync or mutation may run against one profile's session and the
+	// other's database
+	a.mutationLock.Lock()
+	defer a.mutationLock.Unlock()
+
+	a.backgroundLock.Lock()
+	defer a.backgroundLock.Unlock()
+
+	if err := config.SetActiveProfile(a.configPath, name); err != nil {
+		return fmt.Errorf("switch profile: %w", err)
+	}
+
+	if err := a.db.SwitchFile(databasePath(a.appDir, name), a.schema); errors.Is(err, database.ErrClosePrevious) {
+		// the new database is in use; only the old handle leaked
+		fmt.Printf("Warning: %v\n", err)
+	} else if err != nil {
+		if revertErr := config.SetActiveProfile(a.configPath, previous); revertErr != nil {
+			return fmt.Errorf("open database for profile %q: %w; restoring profile %q also failed: %v", name, err, previous, revertErr)
+		}
+		return fmt.Errorf("open database for profile %q: %w", name, err)
+	}
+
+	a.resetSession()
+	a.setBlockedSync(nil)
+	go a.recoverIntents()
+	return nil
 }
 
-func NewApp(db *database.Database, configPath string) *App {
-	return &App{
-		db:         db,
-		configPath: configPath,
+func (a *App) AddProfile(name string, cfg config.Config) error {
+	if err := warnPlaintextToken(config.AddProfile(a.configPath, strings.TrimSpace(name), cfg)); err != nil {
+		return fmt.Errorf("add profile: %w", err)
+	}
+	return nil
+}
+
+func (a *App) RemoveProfile(name string) error {
+	if err := config.RemoveProfile(a.configPath, name); err != nil {
+		return fmt.Errorf("remove profile: %w", err)
+	}
+
+	if err := os.Remove(databasePath(a.appDir, name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("profile removed but deleting its local database failed: %w", err)
+	}
+
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// Session
+// -----------------------------------------------------------------------------
+
+func (a *App) InitializeSession() error {
+	a.sessionLock.Lock()
+	defer a.sessionLock.Unlock()
+
+	_, err := a.initializeSessionLocked()
+	return err
+}
+
+func (a *App) initializeSessionLocked() (*session.CloudflareSession, error) {
+	cfg, err := config.LoadConfig(a.configPath)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.IsComplete() {
+		return nil, fmt.Errorf("config is incomplete")
+	}
+
+	cfSession, err := session.NewCloudflareSession(*cfg)
+	if err != nil {
+		return nil, fmt.Errorf("initialize cloudflare session: %w", err)
+	}
+
+	a.cloudflareSession = cfSession
+	return cfSession, nil
+}
+
+func (a *App) validateKey(key string) error {
+	cfg, err := config.LoadConfig(a.configPath)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	if err := validation.ValidateKey(key, cfg.KeyPolicy); err != nil {
+		return fmt.Errorf("invalid key: %w", err)
+	}
+
+	if !strings.HasPrefix(key, cfg.SyncPrefix) {
+		return fmt.Errorf("invalid key: %q is outside the sync prefix %q of this profile", key, cfg.SyncPrefix)
+	}
+
+	return nil
+}
+
+// ensureSession returns the current session, creating it if needed. Callers
+// keep the returned value rather than reading the field again, since a
+// profile switch or config change may replace it.
+func (a *App) ensureSession() (*session.CloudflareSession, error) {
+	a.sessionLock.Lock()
+	defer a.sessionLock.Unlock()
+
+	if a.cloudflareSession != nil {
+		return a.cloudflareSession, nil
+	}
+	return a.initializeSessionLocked()
+}
+
+func (a *App) resetSession() {
+	a.sessionLock.Lock()
+	defer a.sessionLock.Unlock()
+	a.cloudflareSession = nil
+}
+
+// -----------------------------------------------------------------------------
+// Sync
+// -----------------------------------------------------------------------------
+
+func (a *App) SyncFromCloudflare() error {
+	a.mutationLock.Lock()
+	defer a.mutationLock.Unlock()
+
+	return a.syncActive(nil)
+}
+
+// ConfirmSync reruns a sync the delete guard held back, allowing the deletes
+// the blocked sync reported. A plan that would delete any other key is
+// checked by the guard again.
+func (a *App) ConfirmSync() error {
+	blocked := a.GetBlockedSync()
+	if blocked == nil {
+		return fmt.Errorf("no sync is waiting for confirmation")
+	}
+
+	a.mutationLock.Lock()
+	defer a.mutationLock.Unlock()
+
+	return a.syncActive(blocked)
+}
+
+// syncActive runs a manual sync with the current session. The caller holds
+// mutationLock for writing, so a profile switch cannot pair the session with
+// another profile's database.
+func (a *App) syncActive(confirmed *reconcile.MassDeletionError) error {
+	cfSession, err := a.ensureSession()
+	if err != nil {
+		return err
+	}
+
+	_, err = a.syncWith(cfSession, database.SyncTriggerManual, confirmed)
+	return err
+}
+
+// GetBlockedSync returns the sync waiting for confirmation, or nil.
+func (a *App) GetBlockedSync() *reconcile.MassDeletionError {
+	a.blockedLock.Lock()
+	defer a.blockedLock.Unlock()
+	return a.blockedSync
+}
+
+func (a *App) setBlockedSync(blocked *reconcile.MassDeletionError) {
+	a.blockedLock.Lock()
+	defer a.blockedLock.Unlock()
+	a.blockedSync = blocked
+}
+
+// syncWith reconciles the local database with Cloudflare and records the run
+// in the sync log, whether or not it succeeds. confirmed is the blocked sync
+// the user confirmed, or nil; a plan it allows skips the delete guard.
+func (a *App) syncWith(cfSession *session.CloudflareSession, trigger string, confirmed *reconcile.MassDeletionError) (reconcile.Plan, error) {
+	started := time.Now()
+	run := database.SyncRun{
+		Trigger:   trigger,
+		StartedAt: started.UnixMilli(),
+	}
+
+	plan, err := a.applySync(cfSession, &run, confirmed)
+
+	// any other outcome makes an earlier blocked plan stale
+	var blocked *reconcile.MassDeletionError
+	errors.As(err, &blocked)
+	a.setBlockedSync(blocked)
+
+	finished := time.Now()
+	run.FinishedAt = finished.UnixMilli()
+	run.DurationMs = finished.Sub(started).Milliseconds()
+	if err != nil {
+		run.Error = err.Error()
+	}
+
+	if _, recordErr := a.db.RecordSyncRun(run); recordErr != nil {
+		fmt.Printf("Recording sync run failed: %v\n", recordErr)
+	}
+
+	return plan, err
+}
+
+// applySync fills in run with what was actually applied, so a run that fails
+// part way still lists the changes it made.
+func (a *App) applySync(cfSession *session.CloudflareSession, run *database.SyncRun, confirmed *reconcile.MassDeletionError) (reconcile.Plan, error) {
+	cloudflareEntries, err := cfSession.GetAllEntriesBulk()
+	if err != nil {
+		return reconcile.Plan{}, fmt.Errorf("fetch cloudflare entries: %w", err)
+	}
+	run.RemoteKeys = len(cloudflareEntries)
+
+	databaseEntries, err := a.db.GetAllEntries()
+	if err != nil {
+		return reconcile.Plan{}, fmt.Errorf("fetch database entries: %w", err)
+	}
+	databaseEntries = reconcile.WithPrefix(databaseEntries, cfSession.Prefix())
+
+	plan, err := reconcile.Reconcile(cloudflareEntries, databaseEntries)
+	if err != nil {
+		return reconcile.Plan{}, fmt.Errorf("reconcile entries: %w", err)
+	}
+
+	// keys with queued changes keep their local state until the outbox is flushed
+	pending, err := a.db.OutboxNames()
+	if err != nil {
+		return reconcile.Plan{}, err
+	}
+	plan = plan.Without(pending)
+
+	if !confirmed.Allows(plan) {
+		if err := a.checkDeletes(plan, len(databaseEntries)); err != nil {
+			return reconcile.Plan{}, err
+		}
+	}
+
+	if err := a.db.DeleteNames(plan.ToDelete); err != nil {
+		return reconcile.Plan{}, fmt.Errorf("delete stale database entries: %w", err)
+	}
+	run.Deleted = plan.ToDelete
+
+	toWrite := make([]models.Entry, 0, len(plan.ToInsert)+len(plan.ToUpdate))
+	toWrite = append(toWrite, plan.ToInsert...)
+	toWrite = append(toWrite, plan.ToUpdate...)
+
+	if err := a.db.UpsertEntries(toWrite); err != nil {
+		return reconcile.Plan{}, fmt.Errorf("upsert database entries: %w", err)
+	}
+	run.Inserted = entryNames(plan.ToInsert)
+	run.Updated = entryNames(plan.ToUpdate)
+
+	fmt.Printf(
+		"Sync complete. Inserted: %d, Updated: %d, Deleted: %d\n",
+		len(plan.ToInsert),
+		len(plan.ToUpdate),
+		len(plan.ToDelete),
+	)
+
+	return plan, nil
+}
+
+func (a *App) checkDeletes(plan reconcile.Plan, localRecords int) error {
+	cfg, err := config.LoadConfig(a.configPath)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	if cfg.DeleteGuard.Disabled {
+		return nil
+	}
+
+	return reconcile.CheckDeletes(
+		plan,
+		localRecords,
+		cfg.DeleteGuard.EffectiveMaxDeletes(),
+		cfg.DeleteGuard.EffectiveMaxDeletePercent(),
+	)
+}
+
+func (a *App) SetupAndSync(cfg config.Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	a.mutationLock.Lock()
+	defer a.mutationLock.Unlock()
+
+	if err := session.VerifyCredentials(cfg); err != nil {
+		return fmt.Errorf("verify credentials: %w", err)
+	}
+
+	// setup only edits credentials and domain; other settings are kept
+	if err := warnPlaintextToken(config.SaveSetup(a.configPath, cfg)); err != nil {
+		return fmt.Errorf("save config: %w", err)
+	}
+
+	a.resetSession()
+
+	return a.syncActive(nil)
+}
+
+// -----------------------------------------------------------------------------
+// Auto-sync
+// -----------------------------------------------------------------------------
+
+type AutoSyncStatus struct {
+	Paused          bool   `json:"paused"`
+	IntervalSeconds int    `json:"interval_seconds"`
+	NextRunAt       int64  `json:"next_run_at"`
+	LastRunAt       int64  `json:"last_run_at"`
+	LastError       string `json:"last_error"`
+}
+
+type SyncChanges struct {
+	Inserted int `json:"inserted"`
+	Updated  int `json:"updated"`
+	Deleted  int `json:"deleted"`
+}
+
+func (a *App) runAutoSync(ctx context.Context) {
+	for {
+		delay, enabled := a.scheduleAutoSync()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		if enabled {
+			a.autoSync()
+		}
+	}
+}
+
+// scheduleAutoSync picks the delay before the next run from the active
+// profile's auto_sync settings.
+func (a *App) scheduleAutoSync() (time.Duration, bool) {
+	interval := 0
+	delay := autoSyncIdleCheck
+
+	cfg, err := config.LoadConfig(a.configPath)
+	if err == nil && cfg.IsComplete() && cfg.AutoSync.IntervalSeconds > 0 {
+		interval = cfg.AutoSync.IntervalSeconds
+		delay = time.Duration(interval) * time.Second
+		if cfg.AutoSync.JitterSeconds > 0 {
+			delay += time.Duration(rand.IntN(cfg.AutoSync.JitterSeconds+1)) * time.Second
+		}
+	}
+
+	a.autoSyncLock.Lock()
+	defer a.autoSyncLock.Unlock()
+
+	a.autoSyncStatus.IntervalSeconds = interval
+	a.autoSyncStatus.NextRunAt = 0
+	if interval > 0 {
+		a.autoSyncStatus.NextRunAt = time.Now().Add(delay).Unix()
+	}
+
+	return delay, interval > 0
+}
+
+// autoSync runs one scheduled sync. It is skipped while paused or while a
+// user mutation is in flight.
+func (a *App) autoSync() {
+	if a.GetAutoSyncStatus().Paused {
+		return
+	}
+
+	if !a.mutationLock.TryLock() {
+		fmt.Println("Auto-sync skipped: a change is in progress")
+		return
+	}
+	defer a.mutationLock.Unlock()
+
+	a.backgroundLock.Lock()
+	defer a.backgroundLock.Unlock()
+
+	plan, err := a.syncFromConfig()
+
+	a.autoSyncLock.Lock()
+	a.autoSyncStatus.LastRunAt = time.Now().Unix()
+	a.autoSyncStatus.LastError = ""
+	if err != nil {
+		a.autoSyncStatus.LastError = err.Error()
+	}
+	a.autoSyncLock.Unlock()
+
+	if err != nil {
+		var blocked *reconcile.MassDeletionError
+		if errors.As(err, &blocked) && a.ctx != nil {
+			runtime.EventsEmit(a.ctx, syncBlockedEvent, blocked)
+		}
+		fmt.Printf("Auto-sync failed: %v\n", err)
+		return
+	}
+
+	changes := SyncChanges{
+		Inserted: len(plan.ToInsert),
+		Updated:  len(plan.ToUpdate),
+		Deleted:  len(plan.ToDelete),
+	}
+	if changes.Inserted+changes.Updated+changes.Deleted > 0 && a.ctx != nil {
+		runtime.EventsEmit(a.ctx, syncChangedEvent, changes)
+	}
+}
+
+// syncFromConfig syncs with a session of its own, so background work does not
+// share the session used by user actions.
+func (a *App) syncFromConfig() (reconcile.Plan, error) {
+	cfg, err := config.LoadConfig(a.configPath)
+	if err != nil {
+		return reconcile.Plan{}, fmt.Errorf("load config: %w", err)
+	}
+
+	cfSession, err := session.NewCloudflareSession(*cfg)
+	if err != nil {
+		return reconcile.Plan{}, err
+	}
+
+	return a.syncWith(cfSession, database.SyncTriggerAuto, nil)
+}
+
+func (a *App) PauseAutoSync() {
+	a.autoSyncLock.Lock()
+	defer a.autoSyncLock.Unlock()
+	a.autoSyncStatus.Paused = true
+}
+
+func (a *App) ResumeAutoSync() {
+	a.autoSyncLock.Lock()
+	defer a.autoSyncLock.Unlock()
+	a.autoSyncStatus.Paused = false
+}
+
+func (a *App) GetAutoSyncStatus() AutoSyncStatus {
+	a.autoSyncLock.Lock()
+	defer a.autoSyncLock.Unlock()
+	return a.autoSyncStatus
+}
+
+// -----------------------------------------------------------------------------
+// Sync log
+// -----------------------------------------------------------------------------
+
+// ListSyncRuns returns the most recent sync runs first, with change counts.
+// A limit of 0 returns every run.
+func (a *App) ListSyncRuns(limit int) ([]database.SyncRunSummary, error) {
+	return a.db.ListSyncRuns(limit)
+}
+
+// GetSyncRun returns one sync run with the names it inserted, updated and
+// deleted.
+func (a *App) GetSyncRun(id int64) (database.SyncRun, error) {
+	return a.db.GetSyncRun(id)
+}
+
+// -----------------------------------------------------------------------------
+// Account discovery
+// -----------------------------------------------------------------------------
+
+func (a *App) ListAccounts(token string) ([]session.Account, error) {
+	return session.ListAccounts(token)
+}
+
+func (a *App) ListNamespaces(token string, accountID string) ([]session.Namespace, error) {
+	return session.ListNamespaces(token, accountID)
+}
+
+func (a *App) CreateNamespace(token string, accountID string, title string) (session.Namespace, error) {
+	return session.CreateNamespace(token, accountID, title)
+}
+
+// -----------------------------------------------------------------------------
+// data operation primitives
+// -----------------------------------------------------------------------------
+
+func (a *App) Insert(name string, value string, metadata string) error {
+	defer a.trackMutation()()
+
+	cfSession, err := a.ensureSession()
+	if err != nil {
+		return fmt.Errorf("ensure session: %w", err)
+	}
+
+	newEntry, err := a.parseEntry(name, value, metadata)
+	if err != nil {
+		return err
+	}
+
+	if err := a.validateKey(newEntry.Name); err != nil {
+		return err
+	}
+
+	return a.putEntry(cfSession, newEntry)
+}
+
+// Update replaces the value and metadata of an existing record.
+func (a *App) Update(name string, value string, metadata string) error {
+	defer a.trackMutation()()
+
+	cfSession, err := a.ensureSession()
+	if err != nil {
+		return fmt.Errorf("ensure session: %w", err)
+	}
+
+	entry, err := a.parseEntry(name, value, metadata)
+	if err != nil {
+		return err
+	}
+
+	existing, err := a.db.GetEntryByName(entry.Name)
+	if err != nil {
+		return fmt.Errorf("fetch %q from database: %w", entry.Name, err)
+	}
+	if existing.Name == "" {
+		return fmt.Errorf("entry %q not found", entry.Name)
+	}
+
+	return a.putEntry(cfSession, entry)
+}
+
+func (a *App) parseEntry(name string, value string, metadata string) (models.Entry, error) {
+	customFields, err := a.GetCustomFields()
+	if err != nil {
+		return models.Entry{}, err
+	}
+
+	meta, err := validation.ParseMetadataJSON(metadata, customFields)
+	if err != nil {
+		return models.Entry{}, err
+	}
+
+	meta.Modified = time.Now().Unix()
+
+	if err := validation.ValidateMetadata(meta, customFields); err != nil {
+		return models.Entry{}, err
+	}
+
+	entry := models.Entry{
+		Name:     strings.TrimSpace(name),
+		Metadata: meta,
+		Value:    value,
+	}
+
+	if entry.Name == "" {
+		return models.Entry{}, fmt.Errorf("name cannot be empty")
+	}
+
+	return entry, nil
+}
+
+// putEntry writes entry to Cloudflare and the local database, queueing it in
+// the outbox when Cloudflare cannot be reached.
+func (a *App) putEntry(cfSession *session.CloudflareSession, newEntry models.Entry) error {
+	queued, err := a.queueIfPending(newEntry.Name, func() error { return a.db.EnqueuePut(newEntry) })
+	if err != nil || queued {
+		return err
+	}
+
+	intent, err := a.db.BeginIntent(database.IntentPut, []string{newEntry.Name})
+	if err != nil {
+		return err
+	}
+
+	if err := cfSession.WriteEntry(newEntry); err != nil {
+		if !session.IsTransient(err) {
+			return a.abandonIntent(intent, fmt.Errorf("write entry to cloudflare: %w", err))
+		}
+		// the write may have landed, so the intent stays unless the outbox takes over
+		if err := a.enqueue(func() error { return a.db.EnqueuePut(newEntry) }); err != nil {
+			return err
+		}
+		return a.db.CompleteIntent(intent)
+	}
+
+	if err := a.db.UpsertEntry(newEntry); err != nil {
+		return fmt.Errorf("cloudflare write succeeded but local database upsert failed, it will be repaired on next start: %w", err)
+	}
+
+	return a.db.CompleteIntent(intent)
+}
+
+func (a *App) GetCustomFields() ([]config.CustomField, error) {
+	cfg, err := config.LoadConfig(a.configPath)
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+
+	if cfg.CustomFields == nil {
+		return []config.CustomField{}, nil
+	}
+	return cfg.CustomFields, nil
+}
+
+func (a *App) GetMimeTypes() []string {
+	return validation.KnownMimeTypes()
+}
+
+func (a *App) Delete(key string) error {
+	defer a.trackMutation()()
+
+	cfSession, err := a.ensureSession()
+	if err != nil {
+		return fmt.Errorf("ensure session: %w", err)
+	}
+
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return fmt.Errorf("key cannot be empty")
+	}
+
+	queued, err := a.queueIfPending(key, func() error { return a.db.EnqueueDelete(key) })
+	if err != nil || queued {
+		return err
+	}
+
+	intent, err := a.db.BeginIntent(database.IntentDelete, []string{key})
+	if err != nil {
+		return err
+	}
+
+	if err := cfSession.DeleteKeyValue(key); err != nil {
+		if !session.IsTransient(err) {
+			return a.abandonIntent(intent, fmt.Errorf("delete entry from cloudflare: %w", err))
+		}
+		if err := a.enqueue(func() error { return a.db.EnqueueDelete(key) }); err != nil {
+			return err
+		}
+		return a.db.CompleteIntent(intent)
+	}
+
+	if err := a.db.DeleteName(key); err != nil {
+		return fmt.Errorf("cloudflare delete succeeded but local database delete failed, it will be repaired on next start: %w", err)
+	}
+
+	return a.db.CompleteIntent(intent)
+}
+
+func (a *App) Rename(oldName string, newName string) error {
+	defer a.trackMutation()()
+
+	cfSession, err := a.ensureSession()
+	if err != nil {
+		return fmt.Errorf("ensure session: %w", err)
+	}
+
+	oldName = strings.TrimSpace(oldName)
+	newName = strings.TrimSpace(newName)
+
+	if oldName == "" || newName == "" {
+		return fmt.Errorf("old and new names cannot be empty")
+	}
+	if oldName == newName {
+		return fmt.Errorf("new name must differ from old name")
+	}
+
+	if err := a.validateKey(newName); err != nil {
+		return err
+	}
+
+	if err := a.rejectQueued([]string{oldName, newName}); err != nil {
+		return err
+	}
+
+	oldEntry, found, err := cfSession.GetEntry(oldName)
+	if err != nil {
+		return fmt.Errorf("read %q from cloudflare: %w", oldName, err)
+	}
+	if !found {
+		return fmt.Errorf("key %q does not exist in cloudflare", oldName)
+	}
+
+	_, taken, err := cfSession.GetEntry(newName)
+	if err != nil {
+		return fmt.Errorf("read %q from cloudflare: %w", newName, err)
+	}
+	if taken {
+		return fmt.Errorf("key %q already exists in cloudflare", newName)
+	}
+
+	newEntry := oldEntry
+	newEntry.Name = newName
+	newEntry.Metadata.Modified = time.Now().Unix()
+
+	intent, err := a.db.BeginIntent(database.IntentRename, []string{oldName, newName})
+	if err != nil {
+		return err
+	}
+
+	if err := cfSession.WriteEntry(newEntry); err != nil {
+		if session.IsTransient(err) {
+			return fmt.Errorf("write %q to cloudflare: %w", newName, err)
+		}
+		return a.abandonIntent(intent, fmt.Errorf("write %q to cloudflare: %w", newName, err))
+	}
+
+	if err := a.verifyRemoteEntry(cfSession, newEntry); err != nil {
+		return a.rollbackRename(cfSession, intent, newName, fmt.Errorf("verify %q: %w", newName, err))
+	}
+
+	if err := cfSession.DeleteKeyValue(oldName); err != nil {
+		return a.rollbackRename(cfSession, intent, newName, fmt.Errorf("delete %q from cloudflare: %w", oldName, err))
+	}
+
+	if err := a.db.ReplaceEntry(oldName, newEntry); err != nil {
+		return fmt.Errorf("cloudflare rename succeeded but local database update failed, it will be repaired on next start: %w", err)
+	}
+
+	return a.db.CompleteIntent(intent)
+}
+
+func (a *App) verifyRemoteEntry(cfSession *session.CloudflareSession, expected models.Entry) error {
+	actual, found, err := cfSession.GetEntry(expected.Name)
+	if err != nil {
+		return fmt.Errorf("read back from cloudflare: %w", err)
+	}
+	if !found {
+		return fmt.Errorf("key not found after write")
+	}
+
+	expectedHash, err := reconcile.HashEntry(expected)
+	if err != nil {
+		return fmt.Errorf("hash expected entry: %w", err)
+	}
+
+	actualHash, err := reconcile.HashEntry(actual)
+	if err != nil {
+		return fmt.Errorf("hash stored entry: %w", err)
+	}
+
+	if expectedHash != actualHash {
+		return fmt.Errorf("stored entry does not match written entry")
+	}
+
+	return nil
+}
+
+// rollbackRename removes the new key after a failed rename. The intent stays
+// pending if the rollback fails, so recovery reconciles both keys.
+func (a *App) rollbackRename(cfSession *session.CloudflareSession, intent int64, newName string, cause error) error {
+	if err := cfSession.DeleteKeyValue(newName); err != nil {
+		return fmt.Errorf("%w; rollback of %q also failed: %v", cause, newName, err)
+	}
+	return a.abandonIntent(intent, fmt.Errorf("%w; rolled back %q", cause, newName))
+}
+
+// abandonIntent completes the intent of a write that left Cloudflare
+// unchanged, returning cause.
+func (a *App) abandonIntent(intent int64, cause error) error {
+	if err := a.db.CompleteIntent(intent); err != nil {
+		return fmt.Errorf("%w; %v", cause, err)
+	}
+	return cause
+}
+
+type CloneResult struct {
+	Name string `json:"name"`
+	Link string `json:"link"`
+}
+
+func (a *App) GenerateKey() (string, error) {
+	cfg, err := config.LoadConfig(a.configPath)
+	if err != nil {
+		return "", fmt.Errorf("load config: %w", err)
+	}
+
+	prefix := cfg.KeyPolicy.Prefix
+	if prefix == "" {
+		prefix = cfg.SyncPrefix
+	}
+
+	key := prefix + uuid.NewString()
+	if err := validation.ValidateKey(key, cfg.KeyPolicy); err != nil {
+		return "", fmt.Errorf("generated key violates key policy: %w", err)
+	}
+
+	return key, nil
+}
+
+func (a *App) Clone(name string) (CloneResult, error) {
+	defer a.trackMutation()()
+
+	cfSession, err := a.ensureSession()
+	if err != nil {
+		return CloneResult{}, fmt.Errorf("ensure session: %w", err)
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return CloneResult{}, fmt.Errorf("name cannot be empty")
+	}
+
+	source, err := a.db.GetEntryByName(name)
+	if err != nil {
+		return CloneResult{}, fmt.Errorf("fetch %q from database: %w", name, err)
+	}
+	if source.Name == "" {
+		return CloneResult{}, fmt.Errorf("entry %q not found", name)
+	}
+
+	key, err := a.GenerateKey()
+	if err != nil {
+		return CloneResult{}, err
+	}
+
+	clone := source
+	clone.Name = key
+	clone.Metadata.Modified = time.Now().Unix()
+
+	if err := a.rejectQueued([]string{clone.Name}); err != nil {
+		return CloneResult{}, err
+	}
+
+	intent, err := a.db.BeginIntent(database.IntentPut, []string{clone.Name})
+	if err != nil {
+		return CloneResult{}, err
+	}
+
+	if err := cfSession.WriteEntry(clone); err != nil {
+		if session.IsTransient(err) {
+			return CloneResult{}, fmt.Errorf("write clone to cloudflare: %w", err)
+		}
+		return CloneResult{}, a.abandonIntent(intent, fmt.Errorf("write clone to cloudflare: %w", err))
+	}
+
+	if err := a.db.UpsertEntry(clone); err != nil {
+		return CloneResult{}, fmt.Errorf("cloudflare write succeeded but local database upsert failed, it will be repaired on next start: %w", err)
+	}
+
+	if err := a.db.CompleteIntent(intent); err != nil {
+		return CloneResult{}, err
+	}
+
+	domain, err := a.GetDomain()
+	if err != nil {
+		return CloneResult{}, err
+	}
+
+	return CloneResult{
+		Name: clone.Name,
+		Link: entryLink(domain, clone.Name),
+	}, nil
+}
+
+func entryLink(domain string, id string) string {
+	domain = strings.TrimRight(strings.TrimSpace(domain), "/")
+	if domain == "" {
+		return "?id=" + id
+	}
+
+	lower := strings.ToLower(domain)
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+		domain = "https://" + domain
+	}
+
+	return domain + "/?id=" + id
+}
+
+// -----------------------------------------------------------------------------
+// Bulk operations
+// -----------------------------------------------------------------------------
+
+type KeyFailure struct {
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+type BulkUpdateDiff struct {
+	Name    string               `json:"name"`
+	Changes []models.FieldChange `json:"changes"`
+}
+
+type BulkUpdatePreview struct {
+	Matched  int              `json:"matched"`
+	Affected int              `json:"affected"`
+	Diffs    []BulkUpdateDiff `json:"diffs"`
+	Invalid  []KeyFailure     `json:"invalid"`
+}
+
+type BulkUpdateResult struct {
+	Matched int          `json:"matched"`
+	Updated []string     `json:"updated"`
+	Failed  []KeyFailure `json:"failed"`
+}
+
+func (a *App) planBulkUpdate(query database.Query, patch models.MetadataPatch) (BulkUpdatePreview, []models.Entry, error) {
+	if query.IsEmpty() {
+		return BulkUpdatePreview{}, nil, fmt.Errorf("bulk update query cannot be empty")
+	}
+	if patch.IsEmpty() {
+		return BulkUpdatePreview{}, nil, fmt.Errorf("bulk update patch cannot be empty")
+	}
+
+	customFields, err := a.GetCustomFields()
+	if err != nil {
+		return BulkUpdatePreview{}, nil, err
+	}
+
+	matched, err := a.db.QueryEntries(query)
+	if err != nil {
+		return BulkUpdatePreview{}, nil, fmt.Errorf("query database entries: %w", err)
+	}
+
+	preview := BulkUpdatePreview{
+		Matched: len(matched),
+		Diffs:   make([]BulkUpdateDiff, 0),
+		Invalid: make([]KeyFailure, 0),
+	}
+	toWrite := make([]models.Entry, 0, len(matched))
+	modified := time.Now().Unix()
+
+	for _, entry := range matched {
+		patched := patch.Apply(entry.Metadata)
+
+		changes := models.DiffMetadata(entry.Metadata, patched)
+		if len(changes) == 0 {
+			continue
+		}
+
+		patched.Modified = modified
+		if err := validation.ValidateMetadata(patched, customFields); err != nil {
+			preview.Invalid = append(preview.Invalid, KeyFailure{Name: entry.Name, Error: err.Error()})
+			continue
+		}
+
+		preview.Diffs = append(preview.Diffs, BulkUpdateDiff{Name: entry.Name, Changes: changes})
+
+		entry.Metadata = patched
+		toWrite = append(toWrite, entry)
+	}
+
+	preview.Affected = len(toWrite)
+
+	return preview, toWrite, nil
+}
+
+func (a *App) PreviewBulkUpdate(query database.Query, patch models.MetadataPatch) (BulkUpdatePreview, error) {
+	preview, _, err := a.planBulkUpdate(query, patch)
+	return preview, err
+}
+
+func (a *App) BulkUpdate(query database.Query, patch models.MetadataPatch) (BulkUpdateResult, error) {
+	defer a.trackMutation()()
+
+	cfSession, err := a.ensureSession()
+	if err != nil {
+		return BulkUpdateResult{}, fmt.Errorf("ensure session: %w", err)
+	}
+
+	preview, toWrite, err := a.planBulkUpdate(query, patch)
+	if err != nil {
+		return BulkUpdateResult{}, err
+	}
+
+	if err := a.rejectQueued(entryNames(toWrite)); err != nil {
+		return BulkUpdateResult{}, err
+	}
+
+	result := BulkUpdateResult{
+		Matched: preview.Matched,
+		Updated: make([]string, 0, len(toWrite)),
+		Failed:  append(make([]KeyFailure, 0, len(preview.Invalid)), preview.Invalid...),
+	}
+
+	intent, err := a.db.BeginIntent(database.IntentPut, entryNames(toWrite))
+	if err != nil {
+		return result, err
+	}
+
+	written, failed, uncertain := writeEntriesInChunks(cfSession, toWrite)
+	result.Failed = append(result.Failed, failed...)
+
+	if err := a.db.UpsertEntries(written); err != nil {
+		return result, fmt.Errorf("cloudflare write succeeded but local database upsert failed, it will be repaired on next start: %w", err)
+	}
+
+	for _, entry := range written {
+		result.Updated = append(result.Updated, entry.Name)
+	}
+
+	// a chunk that failed transiently may still have been stored, so leave
+	// the intent for recovery to reconcile
+	if uncertain {
+		return result, nil
+	}
+
+	return result, a.db.CompleteIntent(intent)
+}
+
+type BulkDeleteResult struct {
+	Deleted []string     `json:"deleted"`
+	Failed  []KeyFailure `json:"failed"`
+}
+
+func (a *App) DeleteMany(names []string) (BulkDeleteResult, error) {
+	defer a.trackMutation()()
+
+	cfSession, err := a.ensureSession()
+	if err != nil {
+		return BulkDeleteResult{}, fmt.Errorf("ensure session: %w", err)
+	}
+
+	keys := uniqueKeys(names)
+	if len(keys) == 0 {
+		return BulkDeleteResult{}, fmt.Errorf("no keys to delete")
+	}
+
+	return a.deleteKeys(cfSession, keys)
+}
+
+// uniqueKeys trims names and drops blanks and repeats, keeping the first
+// occurrence of each.
+func uniqueKeys(names []string) []string {
+	seen := make(map[string]struct{}, len(names))
+	keys := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		keys = append(keys, name)
+	}
+	return keys
+}
+
+func (a *App) DeleteMatching(query database.Query) (BulkDeleteResult, error) {
+	defer a.trackMutation()()
+
+	cfSession, err := a.ensureSession()
+	if err != nil {
+		return BulkDeleteResult{}, fmt.Errorf("ensure session: %w", err)
+	}
+
+	if query.IsEmpty() {
+		return BulkDeleteResult{}, fmt.Errorf("delete query cannot be empty")
+	}
+
+	matched, err := a.db.QueryEntries(query)
+	if err != nil {
+		return BulkDeleteResult{}, fmt.Errorf("query database entries: %w", err)
+	}
+
+	keys := make([]string, 0, len(matched))
+	for _, entry := range matched {
+		keys = append(keys, entry.Name)
+	}
+
+	return a.deleteKeys(cfSession, keys)
+}
+
+func (a *App) MergeDuplicates(keep string, remove []string) (BulkDeleteResult, error) {
+	defer a.trackMutation()()
+
+	cfSession, err := a.ensureSession()
+	if err != nil {
+		return BulkDeleteResult{}, fmt.Errorf("ensure session: %w", err)
+	}
+
+	keep = strings.TrimSpace(keep)
+	if keep == "" {
+		return BulkDeleteResult{}, fmt.Errorf("key to keep cannot be empty")
+	}
+
+	kept, err := a.db.GetEntryByName(keep)
+	if err != nil {
+		return BulkDeleteResult{}, fmt.Errorf("fetch %q from database: %w", keep, err)
+	}
+	if kept.Name == "" {
+		return BulkDeleteResult{}, fmt.Errorf("entry %q not found", keep)
+	}
+
+	groups, err := a.db.FindDuplicates()
+	if err != nil {
+		return BulkDeleteResult{}, fmt.Errorf("find duplicates: %w", err)
+	}
+
+	// only keys that share a duplicate group with keep may be merged into it
+	duplicates := make(map[string]struct{})
+	for _, group := range groups {
+		members := make(map[string]struct{}, len(group.Names))
+		for _, name := range group.Names {
+			members[name] = struct{}{}
+		}
+		if _, ok := members[keep]; !ok {
+			continue
+		}
+		for name := range members {
+			duplicates[name] = struct{}{}
+		}
+	}
+
+	keys := uniqueKeys(remove)
+	if len(keys) == 0 {
+		return BulkDeleteResult{}, fmt.Errorf("no keys to delete")
+	}
+
+	for _, name := range keys {
+		if name == keep {
+			return BulkDeleteResult{}, fmt.Errorf("cannot delete the key being kept: %q", keep)
+		}
+		if _, ok := duplicates[name]; !ok {
+			return BulkDeleteResult{}, fmt.Errorf("key %q is not a duplicate of %q", name, keep)
+		}
+	}
+
+	// the mutation lock is already held, so delete without going through DeleteMany
+	return a.deleteKeys(cfSession, keys)
+}
+
+// deleteKeys deletes keys from Cloudflare and the local database under one
+// intent, which stays pending if any local delete fails or any chunk failed
+// transiently. Keys with queued changes are refused.
+func (a *App) deleteKeys(cfSession *session.CloudflareSession, keys []string) (BulkDeleteResult, error) {
+	if err := a.rejectQueued(keys); err != nil {
+		return BulkDeleteResult{}, err
+	}
+
+	intent, err := a.db.BeginIntent(database.IntentDelete, keys)
+	if err != nil {
+		return BulkDeleteResult{}, err
+	}
+
+	localFailed := false
+	deleted, failed, uncertain := deleteKeysInChunks(cfSession, keys, func(chunk []string) error {
+		err := a.db.DeleteNames(chunk)
+		if err != nil {
+			localFailed = true
+		}
+		return err
+	})
+
+	result := BulkDeleteResult{
+		Deleted: deleted,
+		Failed:  failed,
+	}
+
+	if localFailed || uncertain {
+		return result, nil
+	}
+
+	return result, a.db.CompleteIntent(intent)
+}
+
+// writeEntriesInChunks writes entries in requests of at most
+// session.MaxBulkWriteKeys, returning the entries that were written and a
+// failure for every entry of a rejected request or that Cloudflare reported
+// as not stored. uncertain is set when a request failed transiently, so
+// Cloudflare may have applied it anyway.
+func writeEntriesInChunks(cfSession *session.CloudflareSession, entries []models.Entry) (written []models.Entry, failed []KeyFailure, uncertain bool) {
+	written = make([]models.Entry, 0, len(entries))
+	failed = make([]KeyFailure, 0)
+
+	for start := 0; start < len(entries); start += session.MaxBulkWriteKeys {
+		end := start + session.MaxBulkWriteKeys
+		if end > len(entries) {
+			end = len(entries)
+		}
+
+		chunk := entries[start:end]
+
+		unsuccessful, err := cfSession.WriteEntries(chunk)
+		if err != nil {
+			if session.IsTransient(err) {
+				uncertain = true
+			}
+			for _, entry := range chunk {
+				failed = append(failed, KeyFailure{
+					Name:  entry.Name,
+					Error: fmt.Sprintf("write entries %d:%d to cloudflare: %v", start, end, err),
+				})
+			}
+			continue
+		}
+
+		notStored := make(map[string]bool, len(unsuccessful))
+		for _, name := range unsuccessful {
+			notStored[name] = true
+		}
+
+		for _, entry := range chunk {
+			if notStored[entry.Name] {
+				failed = append(failed, KeyFailure{
+					Name:  entry.Name,
+					Error: "cloudflare did not store the key",
+				})
+				continue
+			}
+			written = append(written, entry)
+		}
+	}
+
+	return written, failed, uncertain
+}
+
+// deleteKeysInChunks deletes keys in requests of at most
+// session.MaxBulkDeleteKeys. afterDelete, when set, is called with the keys of
+// each chunk that Cloudflare deleted, and a failure there fails them all.
+// uncertain is set as for writeEntriesInChunks.
+func deleteKeysInChunks(cfSession *session.CloudflareSession, keys []string, afterDelete func([]string) error) (deleted []string, failed []KeyFailure, uncertain bool) {
+	deleted = make([]string, 0, len(keys))
+	failed = make([]KeyFailure, 0)
+
+	for start := 0; start < len(keys); start += session.MaxBulkDeleteKeys {
+		end := start + session.MaxBulkDeleteKeys
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		chunk := keys[start:end]
+
+		unsuccessful, err := cfSession.DeleteKeyValues(chunk)
+		if err != nil {
+			if session.IsTransient(err) {
+				uncertain = true
+			}
+			for _, key := range chunk {
+				failed = append(failed, KeyFailure{
+					Name:  key,
+					Error: fmt.Sprintf("delete keys %d:%d from cloudflare: %v", start, end, err),
+				})
+			}
+			continue
+		}
+
+		notDeleted := make(map[string]bool, len(unsuccessful))
+		for _, key := range unsuccessful {
+			notDeleted[key] = true
+		}
+
+		removed := make([]string, 0, len(chunk))
+		for _, key := range chunk {
+			if notDeleted[key] {
+				failed = append(failed, KeyFailure{
+					Name:  key,
+					Error: "cloudflare did not delete the key",
+				})
+				continue
+			}
+			removed = append(removed, key)
+		}
+		chunk = removed
+
+		if afterDelete != nil {
+			if err := afterDelete(chunk); err != nil {
+				for _, key := range chunk {
+					failed = append(failed, KeyFailure{
+						Name:  key,
+						Error: fmt.Sprintf("cloudflare delete succeeded but local database delete failed: %v", err),
+					})
+				}
+				continue
+			}
+		}
+
+		deleted = append(deleted, chunk...)
+	}
+
+	return deleted, failed, uncertain
+}
+
+// -----------------------------------------------------------------------------
+// Promotion
+// -----------------------------------------------------------------------------
+
+type PromotePlan struct {
+	FromProfile string   `json:"from_profile"`
+	ToProfile   string   `json:"to_profile"`
+	Mirror      bool     `json:"mirror"`
+	ToInsert    []string `json:"to_insert"`
+	ToUpdate    []string `json:"to_update"`
+	ToDelete    []string `json:"to_delete"`
+}
+
+type PromoteResult struct {
+	Written []string     `json:"written"`
+	Deleted []string     `json:"deleted"`
+	Failed  []KeyFailure `json:"failed"`
+}
+
+func (a *App) sessionForProfile(name string) (*session.CloudflareSession, error) {
+	cfg, err := config.LoadProfile(a.configPath, name)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.IsComplete() {
+		return nil, fmt.Errorf("profile %q is incomplete", name)
+	}
+
+	return session.NewCloudflareSession(*cfg)
+}
+
+// planPromote diffs the source entries against the destination. With names
+// set only those keys are promoted; mirror mode promotes the whole source
+// namespace and also deletes destination keys that the source lacks.
+func (a *App) planPromote(names []string, fromProfile string, toProfile string, mirror bool) (PromotePlan, reconcile.Plan, *session.CloudflareSession, error) {
+	if fromProfile == toProfile {
+		return PromotePlan{}, reconcile.Plan{}, nil, fmt.Errorf("source and destination profiles must differ")
+	}
+	if mirror && len(names) > 0 {
+		return PromotePlan{}, reconcile.Plan{}, nil, fmt.Errorf("mirror mode promotes every key and cannot be limited to names")
+	}
+	if !mirror && len(names) == 0 {
+		return PromotePlan{}, reconcile.Plan{}, nil, fmt.Errorf("no keys to promote")
+	}
+
+	source, err := a.sessionForProfile(fromProfile)
+	if err != nil {
+		return PromotePlan{}, reconcile.Plan{}, nil, fmt.Errorf("source profile: %w", err)
+	}
+
+	destination, err := a.sessionForProfile(toProfile)
+	if err != nil {
+		return PromotePlan{}, reconcile.Plan{}, nil, fmt.Errorf("destination profile: %w", err)
+	}
+
+	var sourceEntries, destinationEntries []models.Entry
+	if mirror {
+		sourceEntries, err = source.GetAllEntriesBulk()
+	} else {
+		sourceEntries, err = source.GetEntries(names)
+	}
+	if err != nil {
+		return PromotePlan{}, reconcile.Plan{}, nil, fmt.Errorf("fetch source entries: %w", err)
+	}
+
+	if !mirror && len(sourceEntries) != len(names) {
+		found := make(map[string]struct{}, len(sourceEntries))
+		for _, entry := range sourceEntries {
+			found[entry.Name] = struct{}{}
+		}
+		for _, name := range names {
+			if _, ok := found[name]; !ok {
+				return PromotePlan{}, reconcile.Plan{}, nil, fmt.Errorf("key %q does not exist in profile %q", name, fromProfile)
+			}
+		}
+	}
+
+	if mirror {
+		destinationEntries, err = destination.GetAllEntriesBulk()
+		// mirroring a prefix-scoped source only replaces that prefix
+		destinationEntries = reconcile.WithPrefix(destinationEntries, source.Prefix())
+	} else {
+		destinationEntries, err = destination.GetEntries(names)
+	}
+	if err != nil {
+		return PromotePlan{}, reconcile.Plan{}, nil, fmt.Errorf("fetch destination entries: %w", err)
+	}
+
+	plan, err := reconcile.Reconcile(sourceEntries, destinationEntries)
+	if err != nil {
+		return PromotePlan{}, reconcile.Plan{}, nil, fmt.Errorf("reconcile entries: %w", err)
+	}
+	if !mirror {
+		plan.ToDelete = []string{}
+	}
+
+	preview := PromotePlan{
+		FromProfile: fromProfile,
+		ToProfile:   toProfile,
+		Mirror:      mirror,
+		ToInsert:    entryNames(plan.ToInsert),
+		ToUpdate:    entryNames(plan.ToUpdate),
+		ToDelete:    plan.ToDelete,
+	}
+
+	return preview, plan, destination, nil
+}
+
+func (a *App) PreviewPromote(names []string, fromProfile string, toProfile string, mirror bool) (PromotePlan, error) {
+	preview, _, _, err := a.planPromote(names, fromProfile, toProfile, mirror)
+	return preview, err
+}
+
+// Promote applies the plan PreviewPromote showed. confirmedDeletes is the
+// preview's ToDelete; if the plan now deletes any other key, for example
+// because the source listing came back truncated, nothing is changed.
+func (a *App) Promote(names []string, fromProfile string, toProfile string, mirror bool, confirmedDeletes []string) (PromoteResult, error) {
+	defer a.trackMutation()()
+
+	_, plan, destination, err := a.planPromote(names, fromProfile, toProfile, mirror)
+	if err != nil {
+		return PromoteResult{}, err
+	}
+
+	confirmed := make(map[string]bool, len(confirmedDeletes))
+	for _, name := range confirmedDeletes {
+		confirmed[name] = true
+	}
+	for _, name := range plan.ToDelete {
+		if !confirmed[name] {
+			return PromoteResult{}, fmt.Errorf("promotion would delete %q, which the preview did not list; preview again", name)
+		}
+	}
+
+	toWrite := make([]models.Entry, 0, len(plan.ToInsert)+len(plan.ToUpdate))
+	toWrite = append(toWrite, plan.ToInsert...)
+	toWrite = append(toWrite, plan.ToUpdate...)
+
+	// keep the local cache current when promoting into the profile in use
+	active, err := config.ActiveProfile(a.configPath)
+	if err != nil {
+		return PromoteResult{}, fmt.Errorf("resolve active profile: %w", err)
 	}
-}
+	local := active == toProfile
 
-func (a *App) startup(ctx context.Context) {
-	a.ctx = ctx
+	var intent int64
+	if local {
+		if err := a.rejectQueued(append(entryNames(toWrite), plan.ToDelete...)); err != nil {
+			return PromoteResult{}, err
+		}
+
+		intent, err = a.db.BeginIntent(database.IntentPromote, append(entryNames(toWrite), plan.ToDelete...))
+		if err != nil {
+			return PromoteResult{}, err
+		}
+	}
+
+	written, failed, writeUncertain := writeEntriesInChunks(destination, toWrite)
+
+	localFailed := false
+	var afterDelete func([]string) error
+	if local {
+		afterDelete = func(chunk []string) error {
+			err := a.db.DeleteNames(chunk)
+			if err != nil {
+				localFailed = true
+			}
+			return err
+		}
+	}
+
+	deleted, deleteFailed, deleteUncertain := deleteKeysInChunks(destination, plan.ToDelete, afterDelete)
+
+	result := PromoteResult{
+		Written: entryNames(written),
+		Deleted: deleted,
+		Failed:  append(failed, deleteFailed...),
+	}
+
+	if !local {
+		return result, nil
+	}
+
+	if err := a.db.UpsertEntries(written); err != nil {
+		return result, fmt.Errorf("cloudflare write succeeded but local database upsert failed, it will be repaired on next start: %w", err)
+	}
+	if localFailed || writeUncertain || deleteUncertain {
+		return result, nil
+	}
+
+	return result, a.db.CompleteIntent(intent)
 }
 
-func (a *App) ShowAlert(message string) {
-	runtime.MessageDialog(a.ctx, runtime.MessageDialogOptions{
-		Title:   "Alert",
-		Message: message,
-		Type:    runtime.InfoDialog,
-	})
+func entryNames(entries []models.Entry) []string {
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 // -----------------------------------------------------------------------------
-// Config
+// Offline outbox
 // -----------------------------------------------------------------------------
 
-func (a *App) HasConfig() bool {
-	cfg, err := config.LoadConfig(a.configPath)
+// queueIfPending queues a mutation behind earlier ones for the same key, so
+// they reach Cloudflare in order.
+func (a *App) queueIfPending(name string, queue func() error) (bool, error) {
+	pending, err := a.db.OutboxNames()
 	if err != nil {
-		return false
+		return false, err
 	}
-	return cfg.IsComplete()
+	if !pending[name] {
+		return false, nil
+	}
+	return true, a.enqueue(queue)
 }
 
-func (a *App) IsConfigured() bool {
-	return a.HasConfig()
+// rejectQueued fails if any of names has queued changes. Bulk operations and
+// renames write to Cloudflare directly, so they must not overtake the outbox.
+func (a *App) rejectQueued(names []string) error {
+	pending, err := a.db.OutboxNames()
+	if err != nil {
+		return err
+	}
+
+	queued := make([]string, 0)
+	for _, name := range names {
+		if pending[name] {
+			queued = append(queued, name)
+		}
+	}
+	if len(queued) > 0 {
+		sort.Strings(queued)
+		return fmt.Errorf("keys with queued changes must be flushed or discarded first: %s", strings.Join(queued, ", "))
+	}
+
+	return nil
 }
 
-func (a *App) GetDomain() (string, error) {
-	cfg, err := config.LoadConfig(a.configPath)
+func (a *App) enqueue(queue func() error) error {
+	if err := queue(); err != nil {
+		return fmt.Errorf("queue change for later: %w", err)
+	}
+
+	select {
+	case a.outboxWake <- struct{}{}:
+	default:
+	}
+
+	a.emitOutboxChanged()
+	return nil
+}
+
+func (a *App) emitOutboxChanged() {
+	if a.ctx == nil {
+		return
+	}
+
+	items, err := a.db.ListOutbox()
 	if err != nil {
-		return "", fmt.Errorf("load config: %w", err)
+		return
 	}
-	return cfg.Domain, nil
+	runtime.EventsEmit(a.ctx, outboxChangedEvent, len(items))
 }
 
-func (a *App) SaveConfig(cfg config.Config) error {
-	return config.SaveConfig(a.configPath, cfg)
+func (a *App) runOutbox(ctx context.Context) {
+	ticker := time.NewTicker(outboxInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-a.outboxWake:
+		}
+
+		if err := a.FlushOutbox(); err != nil {
+			fmt.Printf("Outbox flush failed: %v\n", err)
+		}
+	}
 }
 
-// -----------------------------------------------------------------------------
-// Session
-// -----------------------------------------------------------------------------
+// FlushOutbox sends queued mutations to Cloudflare oldest first. A failed item
+// is retried with exponential backoff and holds back later items for the same
+// key; a connectivity failure ends the flush early.
+func (a *App) FlushOutbox() error {
+	a.backgroundLock.Lock()
+	defer a.backgroundLock.Unlock()
 
-func (a *App) InitializeSession() error {
-	cfg, err := config.LoadConfig(a.configPath)
+	items, err := a.db.ListOutbox()
 	if err != nil {
 		return err
 	}
-	if !cfg.IsComplete() {
-		return fmt.Errorf("config is incomplete")
+	if len(items) == 0 {
+		return nil
+	}
+
+	cfg, err := config.LoadConfig(a.configPath)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
 	}
 
 	cfSession, err := session.NewCloudflareSession(*cfg)
 	if err != nil {
-		return fmt.Errorf("initialize cloudflare session: %w", err)
+		return err
+	}
+
+	now := time.Now()
+	blocked := make(map[string]bool)
+	changed := false
+
+	for _, item := range items {
+		name := item.Entry.Name
+		if blocked[name] {
+			continue
+		}
+		if item.NextAttemptAt > now.Unix() {
+			blocked[name] = true
+			continue
+		}
+
+		var sendErr error
+		switch item.Op {
+		case database.OutboxPut:
+			sendErr = cfSession.WriteEntry(item.Entry)
+		case database.OutboxDelete:
+			sendErr = cfSession.DeleteKeyValue(name)
+		default:
+			sendErr = fmt.Errorf("unknown outbox operation %q", item.Op)
+		}
+
+		changed = true
+
+		if sendErr != nil {
+			next := now.Add(outboxBackoff(item.Attempts + 1)).Unix()
+			if err := a.db.FailOutboxItem(item.ID, sendErr, next); err != nil {
+				return err
+			}
+			blocked[name] = true
+
+			if session.IsTransient(sendErr) {
+				break
+			}
+			continue
+		}
+
+		if err := a.db.CompleteOutboxItem(item.ID); err != nil {
+			return err
+		}
+	}
+
+	if changed {
+		a.emitOutboxChanged()
 	}
 
-	a.cloudflareSession = cfSession
 	return nil
 }
 
-func (a *App) ensureSession() error {
-	if a.cloudflareSession != nil {
-		return nil
+func outboxBackoff(attempts int) time.Duration {
+	backoff := outboxBaseBackoff
+	for i := 1; i < attempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
 	}
-	return a.InitializeSession()
+	if backoff > outboxMaxBackoff {
+		backoff = outboxMaxBackoff
+	}
+	return backoff
 }
 
-// -----------------------------------------------------------------------------
-// Sync
-// -----------------------------------------------------------------------------
+func (a *App) ListOutbox() ([]database.OutboxItem, error) {
+	return a.db.ListOutbox()
+}
 
-func (a *App) SyncFromCloudflare() error {
-	if err := a.ensureSession(); err != nil {
+// DiscardOutboxItem drops a queued mutation. The local database keeps the
+// change until the next sync restores the record from Cloudflare.
+func (a *App) DiscardOutboxItem(id int64) error {
+	if err := a.db.DiscardOutboxItem(id); err != nil {
 		return err
 	}
+	a.emitOutboxChanged()
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// Intent recovery
+// -----------------------------------------------------------------------------
+
+// recoverIntents re-reconciles the keys of every intent left behind by an
+// interrupted write, taking Cloudflare's state as the truth. Keys with queued
+// outbox changes are left to the outbox. Intents stay pending if Cloudflare
+// cannot be reached. It excludes mutations, so it never mistakes the intent
+// of a write still in flight for one left behind.
+func (a *App) recoverIntents() {
+	a.mutationLock.Lock()
+	defer a.mutationLock.Unlock()
 
-	cloudflareEntries, err := a.cloudflareSession.GetAllEntriesBulk()
+	a.backgroundLock.Lock()
+	defer a.backgroundLock.Unlock()
+
+	intents, err := a.db.PendingIntents()
 	if err != nil {
-		return fmt.Errorf("fetch cloudflare entries: %w", err)
+		fmt.Printf("Intent recovery failed: %v\n", err)
+		return
+	}
+	if len(intents) == 0 {
+		return
 	}
 
-	databaseEntries, err := a.db.GetAllEntries()
-	if err != nil {
-		return fmt.Errorf("fetch database entries: %w", err)
+	cfg, err := config.LoadConfig(a.configPath)
+	if err != nil || !cfg.IsComplete() {
+		return
 	}
 
-	plan, err := reconcile.Reconcile(cloudflareEntries, databaseEntries)
+	cfSession, err := session.NewCloudflareSession(*cfg)
 	if err != nil {
-		return fmt.Errorf("reconcile entries: %w", err)
+		return
 	}
 
-	if err := a.db.DeleteNames(plan.ToDelete); err != nil {
-		return fmt.Errorf("delete stale database entries: %w", err)
+	pending, err := a.db.OutboxNames()
+	if err != nil {
+		fmt.Printf("Intent recovery failed: %v\n", err)
+		return
 	}
 
-	toWrite := make([]models.Entry, 0, len(plan.ToInsert)+len(plan.ToUpdate))
-	toWrite = append(toWrite, plan.ToInsert...)
-	toWrite = append(toWrite, plan.ToUpdate...)
-
-	if err := a.db.UpsertEntries(toWrite); err != nil {
-		return fmt.Errorf("upsert database entries: %w", err)
-	}
+	for _, intent := range intents {
+		if err := a.recoverKeys(cfSession, intent.Names, pending); err != nil {
+			fmt.Printf("Recovering %s intent %d failed: %v\n", intent.Op, intent.ID, err)
+			continue
+		}
 
-	fmt.Printf(
-		"Sync complete. Inserted: %d, Updated: %d, Deleted: %d\n",
-		len(plan.ToInsert),
-		len(plan.ToUpdate),
-		len(plan.ToDelete),
-	)
+		if err := a.db.CompleteIntent(intent.ID); err != nil {
+			fmt.Printf("Intent recovery failed: %v\n", err)
+			return
+		}
 
-	return nil
+		fmt.Printf("Recovered %s intent %d for %s\n", intent.Op, intent.ID, strings.Join(intent.Names, ", "))
+	}
 }
 
-func (a *App) SetupAndSync(cfg config.Config) error {
-	if !cfg.IsComplete() {
-		return fmt.Errorf("config is incomplete")
+func (a *App) recoverKeys(cfSession *session.CloudflareSession, names []string, skip map[string]bool) error {
+	keys := make([]string, 0, len(names))
+	for _, name := range names {
+		if !skip[name] {
+			keys = append(keys, name)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
 	}
 
-	if err := a.SaveConfig(cfg); err != nil {
-		return fmt.Errorf("save config: %w", err)
+	remote, err := cfSession.GetEntries(keys)
+	if err != nil {
+		return err
 	}
 
-	a.cloudflareSession = nil
+	found := make(map[string]bool, len(remote))
+	for _, entry := range remote {
+		found[entry.Name] = true
+	}
+
+	missing := make([]string, 0)
+	for _, key := range keys {
+		if !found[key] {
+			missing = append(missing, key)
+		}
+	}
 
-	if err := a.ensureSession(); err != nil {
+	if err := a.db.UpsertEntries(remote); err != nil {
 		return err
 	}
 
-	return a.SyncFromCloudflare()
+	return a.db.DeleteNames(missing)
 }
 
 // -----------------------------------------------------------------------------
-// data operation primitives
+// Local files
 // -----------------------------------------------------------------------------
 
-func (a *App) Insert(name string, value string, metadata string) error {
-	if err := a.ensureSession(); err != nil {
-		return fmt.Errorf("ensure session: %w", err)
+type FileDraft struct {
+	Path     string          `json:"path"`
+	Name     string          `json:"name"`
+	Value    string          `json:"value"`
+	Metadata models.Metadata `json:"metadata"`
+}
+
+func (a *App) InsertFromFile(path string) (FileDraft, error) {
+	if strings.TrimSpace(path) == "" {
+		selected, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+			Title: "Select File",
+		})
+		if err != nil {
+			return FileDraft{}, fmt.Errorf("open file dialog: %w", err)
+		}
+		if selected == "" {
+			return FileDraft{}, fmt.Errorf("no file selected")
+		}
+		path = selected
+	}
+
+	return a.draftFromFile(path)
+}
+
+func (a *App) InsertFromFolder(dir string) ([]FileDraft, error) {
+	if strings.TrimSpace(dir) == "" {
+		selected, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+			Title: "Select Folder",
+		})
+		if err != nil {
+			return nil, fmt.Errorf("open directory dialog: %w", err)
+		}
+		if selected == "" {
+			return nil, fmt.Errorf("no folder selected")
+		}
+		dir = selected
 	}
 
-	meta, err := models.MetadataFromJSONString(metadata)
+	paths, err := filescan.ListFiles(dir)
 	if err != nil {
-		return fmt.Errorf("parse metadata: %w", err)
+		return nil, err
 	}
 
-	meta.Modified = time.Now().Unix()
+	drafts := make([]FileDraft, 0, len(paths))
+	for _, path := range paths {
+		draft, err := a.draftFromFile(path)
+		if err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, draft)
+	}
 
-	newEntry := models.Entry{
-		Name:     strings.TrimSpace(name),
-		Metadata: meta,
-		Value:    value,
+	return drafts, nil
+}
+
+func (a *App) draftFromFile(path string) (FileDraft, error) {
+	info, err := filescan.ScanFile(path)
+	if err != nil {
+		return FileDraft{}, fmt.Errorf("scan file: %w", err)
+	}
+
+	key, err := a.GenerateKey()
+	if err != nil {
+		return FileDraft{}, err
 	}
 
-	if newEntry.Name == "" {
-		return fmt.Errorf("name cannot be empty")
+	return FileDraft{
+		Path: info.Path,
+		Name: key,
+		Metadata: models.Metadata{
+			Name:        info.Name,
+			External:    false,
+			MimeType:    info.MimeType,
+			MD5Checksum: info.MD5,
+		},
+	}, nil
+}
+
+func (a *App) VerifyChecksums(dir string) (checksum.Report, error) {
+	if strings.TrimSpace(dir) == "" {
+		selected, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+			Title: "Select Local Mirror Folder",
+		})
+		if err != nil {
+			return checksum.Report{}, fmt.Errorf("open directory dialog: %w", err)
+		}
+		if selected == "" {
+			return checksum.Report{}, fmt.Errorf("no folder selected")
+		}
+		dir = selected
 	}
 
-	if err := a.cloudflareSession.WriteEntry(newEntry); err != nil {
-		return fmt.Errorf("write entry to cloudflare: %w", err)
+	databaseEntries, err := a.db.GetAllEntries()
+	if err != nil {
+		return checksum.Report{}, fmt.Errorf("fetch database entries: %w", err)
 	}
 
-	if err := a.db.UpsertEntry(newEntry); err != nil {
-		return fmt.Errorf("cloudflare write succeeded but local database upsert failed: %w", err)
+	report, err := checksum.Verify(dir, databaseEntries, checksumWorkers)
+	if err != nil {
+		return checksum.Report{}, fmt.Errorf("verify checksums: %w", err)
 	}
 
-	return nil
+	return report, nil
 }
 
-func (a *App) Delete(key string) error {
-	if err := a.ensureSession(); err != nil {
-		return fmt.Errorf("ensure session: %w", err)
+func (a *App) ExportChecksumReport(report checksum.Report) (string, error) {
+	csvContent, err := checksumReportToCSV(report)
+	if err != nil {
+		return "", fmt.Errorf("build csv content: %w", err)
 	}
 
-	key = strings.TrimSpace(key)
-	if key == "" {
-		return fmt.Errorf("key cannot be empty")
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get user home dir: %w", err)
 	}
 
-	if err := a.cloudflareSession.DeleteKeyValue(key); err != nil {
-		return fmt.Errorf("delete entry from cloudflare: %w", err)
+	filePath := filepath.Join(homeDir, "Downloads", checksumReportName)
+	if err := os.WriteFile(filePath, []byte(csvContent), 0644); err != nil {
+		return "", fmt.Errorf("write checksum report: %w", err)
 	}
 
-	if err := a.db.DeleteName(key); err != nil {
-		return fmt.Errorf("cloudflare delete succeeded but local database delete failed: %w", err)
+	openInFinder(filePath)
+	return filePath, nil
+}
+
+// -----------------------------------------------------------------------------
+// Statistics
+// -----------------------------------------------------------------------------
+
+func (a *App) GetStats() (database.Stats, error) {
+	stats, err := a.db.Stats()
+	if err != nil {
+		return database.Stats{}, fmt.Errorf("compute database stats: %w", err)
 	}
+	return stats, nil
+}
 
-	return nil
+// -----------------------------------------------------------------------------
+// Link health
+// -----------------------------------------------------------------------------
+
+type LinkCheckSummary struct {
+	Checked    int `json:"checked"`
+	Broken     int `json:"broken"`
+	Mismatched int `json:"mismatched"`
+}
+
+func (a *App) CheckLinks() (LinkCheckSummary, error) {
+	isExternal := true
+	external, err := a.db.QueryEntries(database.Query{External: &isExternal})
+	if err != nil {
+		return LinkCheckSummary{}, fmt.Errorf("query external entries: %w", err)
+	}
+
+	checks := linkcheck.NewChecker().Check(a.ctx, external)
+
+	if err := a.db.ReplaceLinkChecks(checks); err != nil {
+		return LinkCheckSummary{}, fmt.Errorf("store link checks: %w", err)
+	}
+
+	summary := LinkCheckSummary{Checked: len(checks)}
+	for _, check := range checks {
+		if check.IsBroken() {
+			summary.Broken++
+		}
+		if check.MimeTypeMismatch {
+			summary.Mismatched++
+		}
+	}
+
+	return summary, nil
 }
 
 // -----------------------------------------------------------------------------
@@ -283,8 +2252,50 @@ func entriesToCSV(entries []models.Entry) (string, error) {
 	return buf.String(), nil
 }
 
+func checksumReportToCSV(report checksum.Report) (string, error) {
+	var buf bytes.Buffer
+
+	writer := csv.NewWriter(&buf)
+
+	header := []string{
+		"status",
+		"name",
+		"path",
+		"expected_md5",
+		"actual_md5",
+		"error",
+	}
+
+	if err := writer.Write(header); err != nil {
+		return "", fmt.Errorf("write csv header: %w", err)
+	}
+
+	for _, result := range report.Results {
+		row := []string{
+			result.Status,
+			result.Name,
+			result.Path,
+			result.Expected,
+			result.Actual,
+			result.Error,
+		}
+
+		if err := writer.Write(row); err != nil {
+			return "", fmt.Errorf("write csv row for %q: %w", result.Path, err)
+		}
+	}
+
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		return "", fmt.Errorf("flush csv writer: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
 func (a *App) SaveDatabaseFile() (string, error) {
-	if err := a.ensureSession(); err != nil {
+	if _, err := a.ensureSession(); err != nil {
 		return "", err
 	}
 
@@ -322,7 +2333,7 @@ func (a *App) GenerateDatabaseCSV() (string, error) {
 	return path, nil
 }
 
-func templateToCSV() (string, error) {
+func templateToCSV(customFields []config.CustomField) (string, error) {
 	var buf bytes.Buffer
 
 	writer := csv.NewWriter(&buf)
@@ -339,6 +2350,10 @@ func templateToCSV() (string, error) {
 		"metadata_md5Checksum",
 	}
 
+	for _, field := range customFields {
+		header = append(header, "metadata_"+field.Name)
+	}
+
 	if err := writer.Write(header); err != nil {
 		return "", fmt.Errorf("write template csv header: %w", err)
 	}
@@ -352,8 +2367,8 @@ func templateToCSV() (string, error) {
 	return buf.String(), nil
 }
 
-func SaveTemplateFile() (string, error) {
-	csvContent, err := templateToCSV()
+func SaveTemplateFile(customFields []config.CustomField) (string, error) {
+	csvContent, err := templateToCSV(customFields)
 	if err != nil {
 		return "", fmt.Errorf("build template csv: %w", err)
 	}
@@ -373,7 +2388,12 @@ func SaveTemplateFile() (string, error) {
 }
 
 func (a *App) GenerateCSV() (string, error) {
-	path, err := SaveTemplateFile()
+	customFields, err := a.GetCustomFields()
+	if err != nil {
+		return "", err
+	}
+
+	path, err := SaveTemplateFile(customFields)
 	if err != nil {
 		return "", err
 	}
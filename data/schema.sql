@@ -2,4 +2,43 @@ CREATE TABLE IF NOT EXISTS records (
     name TEXT PRIMARY KEY,
     value TEXT,
     metadata TEXT
-);
\ No newline at end of file
+);
+CREATE TABLE IF NOT EXISTS link_checks (
+    name TEXT PRIMARY KEY,
+    url TEXT,
+    status_code INTEGER,
+    final_url TEXT,
+    content_type TEXT,
+    mimetype_mismatch INTEGER,
+    error TEXT,
+    checked_at INTEGER
+);
+CREATE TABLE IF NOT EXISTS outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    op TEXT NOT NULL,
+    name TEXT NOT NULL,
+    value TEXT NOT NULL DEFAULT '',
+    metadata TEXT NOT NULL DEFAULT '',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL,
+    next_attempt_at INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS intents (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    op TEXT NOT NULL,
+    names TEXT NOT NULL,
+    created_at INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS sync_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    trigger TEXT NOT NULL,
+    started_at INTEGER NOT NULL,
+    finished_at INTEGER NOT NULL,
+    duration_ms INTEGER NOT NULL,
+    remote_keys INTEGER NOT NULL,
+    inserted TEXT NOT NULL,
+    updated TEXT NOT NULL,
+    deleted TEXT NOT NULL,
+    error TEXT NOT NULL DEFAULT ''
+);
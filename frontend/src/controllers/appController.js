@@ -1,4 +1,12 @@
-import { IsConfigured, SyncFromCloudflare, GetDomain, ShowAlert } from '../services/appService';
+import {
+  IsConfigured,
+  SyncFromCloudflare,
+  ConfirmSync,
+  GetBlockedSync,
+  GetDomain,
+  ShowAlert
+} from '../services/appService';
+import { EventsOn } from '../../wailsjs/runtime/runtime';
 import { normalizeDomain } from '../utils/domain';
 import { appState } from '../state/appState';
 import { renderConfigView } from '../views/configView';
@@ -11,7 +19,49 @@ import { bindExportEvents } from './exportController';
 
 const appRoot = document.querySelector('#app');
 
+let confirmingSync = false;
+
+async function confirmBlockedSync(blocked) {
+  // scheduled syncs keep reporting the same plan; ask only once at a time
+  if (confirmingSync) return;
+  confirmingSync = true;
+
+  try {
+    const confirmed = window.confirm(
+      `Cloudflare returned far fewer records than expected. Syncing would delete ${blocked.deletes} of ${blocked.local_records} local records. Apply these deletions?`
+    );
+    if (confirmed) {
+      await ConfirmSync();
+    }
+  } finally {
+    confirmingSync = false;
+  }
+}
+
+async function syncWithConfirmation() {
+  try {
+    await SyncFromCloudflare();
+  } catch (err) {
+    const blocked = await GetBlockedSync();
+    if (!blocked) throw err;
+
+    await confirmBlockedSync(blocked);
+  }
+}
+
+function bindSyncEvents() {
+  EventsOn('sync:blocked', async (blocked) => {
+    try {
+      await confirmBlockedSync(blocked);
+    } catch (err) {
+      ShowAlert(`Sync failed: ${err}`);
+    }
+  });
+}
+
 export async function initializeApp() {
+  bindSyncEvents();
+
   try {
     const configured = await IsConfigured();
 
@@ -22,7 +72,7 @@ export async function initializeApp() {
     }
 
     appState.appDomain = normalizeDomain(await GetDomain());
-    await SyncFromCloudflare();
+    await syncWithConfirmation();
 
     renderMainShell(appRoot);
     bindSearchEvents();
@@ -1,4 +1,11 @@
-import { SetupAndSync, GetDomain, ShowAlert } from '../services/appService';
+import {
+  SetupAndSync,
+  ListAccounts,
+  ListNamespaces,
+  CreateNamespace,
+  GetDomain,
+  ShowAlert
+} from '../services/appService';
 import { normalizeDomain } from '../utils/domain';
 import { appState } from '../state/appState';
 import { renderMainShell } from '../views/shellView';
@@ -8,10 +15,79 @@ import { bindDeleteEvents } from './deleteController';
 
 const appRoot = document.querySelector('#app');
 
+function fillOptions(listId, items, label) {
+  const list = document.getElementById(listId);
+  list.replaceChildren(...items.map((item) => {
+    const option = document.createElement('option');
+    option.value = item.id;
+    option.label = label(item);
+    return option;
+  }));
+}
+
+async function loadAccounts() {
+  const token = document.getElementById('config-cloudflare-api-token').value.trim();
+  if (!token) {
+    ShowAlert('Enter an API token first.');
+    return;
+  }
+
+  try {
+    const accounts = await ListAccounts(token) || [];
+    fillOptions('config-account-options', accounts, (account) => account.name);
+
+    const accountInput = document.getElementById('config-account-id');
+    if (accounts.length === 1 && !accountInput.value.trim()) {
+      accountInput.value = accounts[0].id;
+      await loadNamespaces();
+    }
+  } catch (err) {
+    ShowAlert(`Failed to load accounts. ${err}`);
+  }
+}
+
+async function loadNamespaces() {
+  const token = document.getElementById('config-cloudflare-api-token').value.trim();
+  const accountID = document.getElementById('config-account-id').value.trim();
+  if (!token || !/^[a-f0-9]{32}$/.test(accountID)) return;
+
+  try {
+    const namespaces = await ListNamespaces(token, accountID) || [];
+    fillOptions('config-namespace-options', namespaces, (namespace) => namespace.title);
+  } catch (err) {
+    ShowAlert(`Failed to load namespaces. ${err}`);
+  }
+}
+
+async function createNamespace() {
+  const token = document.getElementById('config-cloudflare-api-token').value.trim();
+  const accountID = document.getElementById('config-account-id').value.trim();
+  const titleInput = document.getElementById('config-new-namespace-title');
+  const title = titleInput.value.trim();
+
+  if (!token || !accountID || !title) {
+    ShowAlert('API token, account ID and a namespace title are required to create a namespace.');
+    return;
+  }
+
+  try {
+    const namespace = await CreateNamespace(token, accountID, title);
+    document.getElementById('config-namespace-id').value = namespace.id;
+    titleInput.value = '';
+    await loadNamespaces();
+  } catch (err) {
+    ShowAlert(`Failed to create namespace. ${err}`);
+  }
+}
+
 export function bindConfigEvents() {
   const form = document.getElementById('config-form');
   if (!form) return;
 
+  document.getElementById('load-accounts-button').addEventListener('click', loadAccounts);
+  document.getElementById('config-account-id').addEventListener('change', loadNamespaces);
+  document.getElementById('create-namespace-button').addEventListener('click', createNamespace);
+
   form.addEventListener('submit', async (e) => {
     e.preventDefault();
 
@@ -28,7 +104,7 @@ export function bindConfigEvents() {
     }
 
     try {
-      document.getElementById('config-status').innerHTML = 'Saving configuration and syncing Cloudflare data...';
+      document.getElementById('config-status').innerHTML = 'Verifying credentials, saving configuration and syncing Cloudflare data...';
       await SetupAndSync(cfg);
       appState.appDomain = normalizeDomain(await GetDomain());
 
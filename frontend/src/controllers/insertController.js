@@ -1,8 +1,14 @@
 import Papa from 'papaparse';
 
-import { GenerateCSV, Insert, ShowAlert } from '../services/appService';
+import {
+  GenerateCSV,
+  GenerateKey,
+  GetCustomFields,
+  Insert,
+  InsertFromFile,
+  ShowAlert
+} from '../services/appService';
 import { appState } from '../state/appState';
-import { generateUUID } from '../utils/uuid';
 
 export function bindInsertEvents() {
   const insertEntrySelector = document.getElementById('insertEntrySelector');
@@ -226,11 +232,12 @@ function updateInsertEntry(entryMethod = undefined) {
 
       clearInsertButton.style.display = 'inline';
 
-      document.getElementById('generate-uuid-button')?.addEventListener('click', handleGenerateUUID);
+      document.getElementById('generate-uuid-button')?.addEventListener('click', handleGenerateKey);
       document.getElementById('insert-entry-button')?.addEventListener('click', insertEntry);
       document
         .getElementById('externalMetadataToggle')
         ?.addEventListener('change', updateExternalInternalMetadataSelector);
+      renderCustomFields();
       break;
 
     case 'fromFile':
@@ -260,6 +267,12 @@ function updateInsertEntry(entryMethod = undefined) {
       document.getElementById('insert-file-button')?.addEventListener('click', insertEntryFromFile);
       break;
 
+    case 'fromLocalFile':
+      updateInsertEntry('manual');
+      selector.value = 'manual';
+      prefillFromLocalFile();
+      break;
+
     case 'getBulkInsertTemplate':
       GenerateCSV();
     default:
@@ -271,10 +284,89 @@ function updateInsertEntry(entryMethod = undefined) {
   }
 }
 
-function handleGenerateUUID() {
+async function renderCustomFields() {
+  const entryMetadata = document.getElementById('entryMetadata');
+  if (!entryMetadata) return;
+
+  let customFields;
+  try {
+    customFields = (await GetCustomFields()) ?? [];
+  } catch (err) {
+    ShowAlert(`Failed to load custom metadata fields. ${err}`);
+    return;
+  }
+
+  customFields.forEach((field) => {
+    const row = document.createElement('div');
+    row.className = 'metadata-entry';
+
+    const keyInput = document.createElement('input');
+    keyInput.className = 'input jsonKey';
+    keyInput.type = 'text';
+    keyInput.spellcheck = false;
+    keyInput.value = field.name;
+    keyInput.readOnly = true;
+    keyInput.style.marginRight = '5px';
+
+    let valueInput;
+    if (field.type === 'boolean') {
+      valueInput = document.createElement('select');
+      valueInput.style.width = '422px';
+      valueInput.innerHTML = `
+        <option value="default" selected disabled>${field.name}</option>
+        <option value="true">True</option>
+        <option value="false">False</option>
+      `;
+    } else {
+      valueInput = document.createElement('input');
+      valueInput.type = field.type === 'number' ? 'number' : 'text';
+      valueInput.spellcheck = false;
+      valueInput.placeholder = field.name;
+    }
+
+    valueInput.classList.add('input', 'jsonValue');
+    valueInput.required = field.required;
+
+    row.append(keyInput, valueInput);
+    entryMetadata.appendChild(row);
+  });
+}
+
+async function prefillFromLocalFile() {
+  let draft;
+  try {
+    draft = await InsertFromFile('');
+  } catch (err) {
+    ShowAlert(`Failed to read local file. ${err}`);
+    return;
+  }
+
+  const nameInput = document.getElementById('insertEntryName');
+  if (nameInput) nameInput.value = draft.name;
+
+  const metadata = draft.metadata ?? {};
+  document.querySelectorAll('.metadata-entry').forEach((entry) => {
+    const key = entry.querySelector('.jsonKey')?.value.trim();
+    const valueInput = entry.querySelector('.jsonValue');
+    if (!key || !valueInput) return;
+
+    if (key === 'external') {
+      valueInput.value = String(metadata.external ?? false);
+      updateExternalInternalMetadataSelector();
+    } else if (metadata[key] !== undefined && metadata[key] !== '') {
+      valueInput.value = metadata[key];
+    }
+  });
+}
+
+async function handleGenerateKey() {
   const entryNameInput = document.getElementById('insertEntryName');
   if (!entryNameInput) return;
-  entryNameInput.value = generateUUID();
+  try {
+    entryNameInput.value = await GenerateKey();
+  } catch (err) {
+    ShowAlert(`Failed to generate key. ${err}`);
+  }
 }
 
 async function insertEntry() {
@@ -1,23 +1,99 @@
 import {
   IsConfigured,
   SetupAndSync,
+  ListAccounts,
+  ListNamespaces,
+  CreateNamespace,
   SyncFromCloudflare,
+  ConfirmSync,
+  GetBlockedSync,
+  PauseAutoSync,
+  ResumeAutoSync,
+  GetAutoSyncStatus,
+  ListSyncRuns,
+  GetSyncRun,
   GenerateCSV,
   GenerateDatabaseCSV,
   ShowAlert,
   GetDomain,
+  GetConfig,
+  UpdateConfig,
+  ListProfiles,
+  SwitchProfile,
+  AddProfile,
+  RemoveProfile,
   Insert,
-  Delete
+  Update,
+  GetMimeTypes,
+  GetCustomFields,
+  InsertFromFile,
+  InsertFromFolder,
+  VerifyChecksums,
+  ExportChecksumReport,
+  CheckLinks,
+  GetStats,
+  Delete,
+  ListOutbox,
+  DiscardOutboxItem,
+  FlushOutbox,
+  Rename,
+  Clone,
+  GenerateKey,
+  PreviewBulkUpdate,
+  BulkUpdate,
+  DeleteMany,
+  DeleteMatching,
+  MergeDuplicates,
+  PreviewPromote,
+  Promote
 } from '../../wailsjs/go/main/App';
 
 export {
   IsConfigured,
   SetupAndSync,
+  ListAccounts,
+  ListNamespaces,
+  CreateNamespace,
   SyncFromCloudflare,
+  ConfirmSync,
+  GetBlockedSync,
+  PauseAutoSync,
+  ResumeAutoSync,
+  GetAutoSyncStatus,
+  ListSyncRuns,
+  GetSyncRun,
   GenerateCSV,
   GenerateDatabaseCSV,
   ShowAlert,
   GetDomain,
+  GetConfig,
+  UpdateConfig,
+  ListProfiles,
+  SwitchProfile,
+  AddProfile,
+  RemoveProfile,
   Insert,
-  Delete
+  Update,
+  GetMimeTypes,
+  GetCustomFields,
+  InsertFromFile,
+  InsertFromFolder,
+  VerifyChecksums,
+  ExportChecksumReport,
+  CheckLinks,
+  GetStats,
+  Delete,
+  ListOutbox,
+  DiscardOutboxItem,
+  FlushOutbox,
+  Rename,
+  Clone,
+  GenerateKey,
+  PreviewBulkUpdate,
+  BulkUpdate,
+  DeleteMany,
+  DeleteMatching,
+  MergeDuplicates,
+  PreviewPromote,
+  Promote
 };
\ No newline at end of file
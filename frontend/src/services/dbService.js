@@ -2,12 +2,16 @@ import {
   GetEntryByName,
   GetEntryByValue,
   GetEntriesByValue,
-  GetAllEntries
+  GetAllEntries,
+  GetBrokenLinks,
+  FindDuplicates
 } from '../../wailsjs/go/database/Database';
 
 export {
   GetEntryByName,
   GetEntryByValue,
   GetEntriesByValue,
-  GetAllEntries
+  GetAllEntries,
+  GetBrokenLinks,
+  FindDuplicates
 };
\ No newline at end of file
@@ -15,6 +15,7 @@ export function renderConfigView(appRoot) {
               spellcheck="false"
               placeholder="Cloudflare API Token"
               style="width:500px;" />
+          <button class="btn" id="load-accounts-button" type="button">Load Accounts</button>
         </div>
 
         <div class="section">
@@ -26,7 +27,9 @@ export function renderConfigView(appRoot) {
               title="Account ID must be 32 lowercase hexadecimal characters"
               spellcheck="false"
               placeholder="Account ID"
+              list="config-account-options"
               style="width:500px;" />
+          <datalist id="config-account-options"></datalist>
         </div>
 
         <div class="section">
@@ -38,7 +41,19 @@ export function renderConfigView(appRoot) {
               title="Namespace ID must be 32 lowercase hexadecimal characters"
               spellcheck="false"
               placeholder="Namespace ID"
+              list="config-namespace-options"
               style="width:500px;" />
+          <datalist id="config-namespace-options"></datalist>
+        </div>
+
+        <div class="section">
+          <input class="input"
+              id="config-new-namespace-title"
+              type="text"
+              spellcheck="false"
+              placeholder="New namespace title"
+              style="width:500px;" />
+          <button class="btn" id="create-namespace-button" type="button">Create Namespace</button>
         </div>
 
         <div class="section">
@@ -20,6 +20,7 @@ export function renderMainShell(appRoot) {
         <option value="default" selected disabled>Select Insertion Method</option>
         <option value="manual">Insert Manually</option>
         <option value="fromFile">From File</option>
+        <option value="fromLocalFile">From Local Asset</option>
         <option value="getBulkInsertTemplate">Download File Template</option>
       </select>
       <button id="clear-insert" class="btn" style="display:none;">Clear</button>
@@ -2,12 +2,17 @@ package main
 
 import (
 	"embed"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"cdnmanager/pkg/config"
 	"cdnmanager/pkg/database"
+	"cdnmanager/pkg/secrets"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -28,9 +33,11 @@ var icon []byte
 
 const appFolderName = "cdnmanager"
 
-func initializeDatabase(dbPath string) (*database.Database, error) {
+func initializeDatabase(dbPath string, schema []byte) (*database.Database, error) {
 	if _, err := os.Stat(dbPath); err == nil {
-		db, err := database.NewDatabase(dbPath)
+		// the schema only uses IF NOT EXISTS, so applying it to an existing
+		// database adds any tables introduced since it was created
+		db, err := database.NewDatabaseFromSchema(dbPath, schema)
 		if err != nil {
 			return nil, fmt.Errorf("open existing database: %w", err)
 		}
@@ -41,11 +48,6 @@ func initializeDatabase(dbPath string) (*database.Database, error) {
 
 	fmt.Println("Database not found. Creating a new one...")
 
-	schema, err := schemaFS.ReadFile("data/schema.sql")
-	if err != nil {
-		return nil, fmt.Errorf("failed to read embedded schema: %w", err)
-	}
-
 	db, err := database.NewDatabaseFromSchema(dbPath, schema)
 	if err != nil {
 		return nil, fmt.Errorf("create database from schema: %w", err)
@@ -66,21 +68,54 @@ func initializeConfig(configPath string) error {
 	return config.SaveConfig(configPath, config.Config{})
 }
 
-func appPaths() (appDir string, dbPath string, configPath string, err error) {
-	userConfigDir, err := os.UserConfigDir()
+// appPaths resolves the directory holding config.json and the databases. The
+// -home flag takes precedence over CDNMANAGER_HOME, which takes precedence over
+// the user config directory.
+func appPaths(homeFlag string) (appDir string, configPath string, err error) {
+	switch {
+	case homeFlag != "":
+		appDir = homeFlag
+	case os.Getenv(config.EnvHome) != "":
+		appDir = os.Getenv(config.EnvHome)
+	default:
+		userConfigDir, err := os.UserConfigDir()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to determine user config directory: %w", err)
+		}
+		appDir = filepath.Join(userConfigDir, appFolderName)
+	}
+
+	appDir, err = filepath.Abs(appDir)
 	if err != nil {
-		return "", "", "", fmt.Errorf("failed to determine user config directory: %w", err)
+		return "", "", fmt.Errorf("failed to resolve app directory: %w", err)
 	}
 
-	appDir = filepath.Join(userConfigDir, appFolderName)
-	dbPath = filepath.Join(appDir, "cdnmanager.sqlite3")
 	configPath = filepath.Join(appDir, "config.json")
 
-	return appDir, dbPath, configPath, nil
+	return appDir, configPath, nil
+}
+
+// databasePath returns the local database file for a profile. The default
+// profile keeps the original file name so existing caches carry over.
+func databasePath(appDir string, profile string) string {
+	if profile == config.DefaultProfile {
+		return filepath.Join(appDir, "cdnmanager.sqlite3")
+	}
+	return filepath.Join(appDir, "cdnmanager-"+profile+".sqlite3")
+}
+
+// parseFlags reads the command line flags. Unknown arguments are ignored so
+// launchers that pass their own arguments do not stop the app from starting.
+func parseFlags(args []string) (home string) {
+	flags := flag.NewFlagSet(appFolderName, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&home, "home", "", "directory holding config.json and the local databases")
+	_ = flags.Parse(args)
+	return home
 }
 
 func main() {
-	appDir, dbPath, configPath, err := appPaths()
+	appDir, configPath, err := appPaths(parseFlags(os.Args[1:]))
 
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "resolve app paths: %v\n", err)
@@ -97,13 +132,40 @@ func main() {
 		os.Exit(1)
 	}
 
-	cdnDB, err := initializeDatabase(dbPath)
+	if passphrase := os.Getenv("CDNMANAGER_SECRETS_PASSPHRASE"); passphrase != "" {
+		secrets.Default.Register(secrets.SchemeFile, secrets.NewFileBackend(filepath.Join(appDir, "secrets.json"), passphrase))
+	}
+
+	if err := config.MigrateConfig(configPath); errors.Is(err, config.ErrPlaintextToken) {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if overrides := config.EnvOverrides(); len(overrides) > 0 {
+		fmt.Printf("Config overridden by environment: %s\n", strings.Join(overrides, ", "))
+	}
+
+	profile, err := config.ActiveProfile(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "resolve active profile: %v\n", err)
+		os.Exit(1)
+	}
+
+	schema, err := schemaFS.ReadFile("data/schema.sql")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read embedded schema: %v\n", err)
+		os.Exit(1)
+	}
+
+	cdnDB, err := initializeDatabase(databasePath(appDir, profile), schema)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to initialize database: %v\n", err)
 		os.Exit(1)
 	}
 
-	app := NewApp(cdnDB, configPath)
+	app := NewApp(cdnDB, configPath, appDir, schema)
 
 	err = wails.Run(&options.App{
 		Title:         "Content Delivery Network Manager",
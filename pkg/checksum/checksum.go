@@ -0,0 +1,204 @@
+package checksum
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"cdnmanager/pkg/filescan"
+	"cdnmanager/pkg/models"
+)
+
+const (
+	StatusMatch    = "match"
+	StatusMismatch = "mismatch"
+	StatusMissing  = "missing"
+	StatusOrphan   = "orphan"
+	StatusError    = "error"
+)
+
+type Result struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Directory  string   `json:"directory"`
+	Matches    int      `json:"matches"`
+	Mismatches int      `json:"mismatches"`
+	Missing    int      `json:"missing"`
+	Orphans    int      `json:"orphans"`
+	Errors     int      `json:"errors"`
+	Results    []Result `json:"results"`
+}
+
+// Verify matches the files under dir to entries carrying an MD5 checksum and
+// recomputes each matched file's checksum using at most workers goroutines.
+// A file matches an entry when its file name, with or without extension,
+// equals the entry's cloud_storage_id, key or metadata name, in that order.
+func Verify(dir string, entries []models.Entry, workers int) (Report, error) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	files, err := indexFiles(dir)
+	if err != nil {
+		return Report{}, err
+	}
+
+	claimed := make(map[string]struct{})
+	jobs := make([]Result, 0)
+	results := make([]Result, 0)
+
+	for _, entry := range entries {
+		if entry.Metadata.MD5Checksum == "" {
+			continue
+		}
+
+		path := ""
+		for _, candidate := range []string{entry.Metadata.CloudStorageID, entry.Name, entry.Metadata.Name} {
+			if paths := files[candidate]; candidate != "" && len(paths) > 0 {
+				path = paths[0]
+				break
+			}
+		}
+
+		result := Result{
+			Name:     entry.Name,
+			Path:     path,
+			Expected: strings.ToLower(entry.Metadata.MD5Checksum),
+		}
+
+		if path == "" {
+			result.Status = StatusMissing
+			results = append(results, result)
+			continue
+		}
+
+		claimed[path] = struct{}{}
+		jobs = append(jobs, result)
+	}
+
+	results = append(results, hashAll(jobs, workers)...)
+
+	orphans := make(map[string]struct{})
+	for _, paths := range files {
+		for _, path := range paths {
+			if _, ok := claimed[path]; !ok {
+				orphans[path] = struct{}{}
+			}
+		}
+	}
+	for path := range orphans {
+		results = append(results, Result{Path: path, Status: StatusOrphan})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Status != results[j].Status {
+			return results[i].Status < results[j].Status
+		}
+		if results[i].Name != results[j].Name {
+			return results[i].Name < results[j].Name
+		}
+		return results[i].Path < results[j].Path
+	})
+
+	report := Report{Directory: dir, Results: results}
+	for _, result := range results {
+		switch result.Status {
+		case StatusMatch:
+			report.Matches++
+		case StatusMismatch:
+			report.Mismatches++
+		case StatusMissing:
+			report.Missing++
+		case StatusOrphan:
+			report.Orphans++
+		case StatusError:
+			report.Errors++
+		}
+	}
+
+	return report, nil
+}
+
+func hashAll(jobs []Result, workers int) []Result {
+	results := make([]Result, len(jobs))
+	queue := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				result := jobs[i]
+
+				actual, err := filescan.MD5File(result.Path)
+				switch {
+				case err != nil:
+					result.Status = StatusError
+					result.Error = err.Error()
+				case actual == result.Expected:
+					result.Actual = actual
+					result.Status = StatusMatch
+				default:
+					result.Actual = actual
+					result.Status = StatusMismatch
+				}
+
+				results[i] = result
+			}
+		}()
+	}
+
+	for i := range jobs {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	return results
+}
+
+// indexFiles maps both the file name and the file name without extension of
+// every regular file under dir to its paths.
+func indexFiles(dir string) (map[string][]string, error) {
+	files := make(map[string][]string)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && path != dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		base := d.Name()
+		stem := strings.TrimSuffix(base, filepath.Ext(base))
+
+		files[base] = append(files[base], path)
+		if stem != base {
+			files[stem] = append(files[stem], path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk directory %q: %w", dir, err)
+	}
+
+	return files, nil
+}
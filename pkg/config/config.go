@@ -1,23 +1,201 @@
 package config
 
 import (
-	"bytes"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
+
+	"cdnmanager/pkg/models"
+	"cdnmanager/pkg/secrets"
 )
 
-const CurrentVersion = 1
+const CurrentVersion = 2
 
 type Config struct {
-	Version int `json:"version"`
+	// Version is only written by version 1 config files, which held a single
+	// profile at the top level.
+	Version int `json:"version,omitempty"`
+
+	// Profile is the name of the profile the config was loaded from.
+	Profile string `json:"-"`
+
+	// CloudflareAPIToken is only stored in plaintext when no secret backend
+	// accepts it; otherwise the file holds CloudflareAPITokenRef and the token
+	// is resolved from the referenced secret store on load.
+	CloudflareAPIToken    string `json:"cloudflare_api_token,omitempty"`
+	CloudflareAPITokenRef string `json:"cloudflare_api_token_ref,omitempty"`
+
+	AccountID   string `json:"account_id"`
+	NamespaceID string `json:"namespace_id"`
+	Domain      string `json:"domain"`
+
+	// SyncPrefix limits listing and sync to keys starting with it. Keys
+	// outside the prefix are left alone locally.
+	SyncPrefix string `json:"sync_prefix,omitempty"`
+
+	KeyPolicy    KeyPolicy     `json:"key_policy"`
+	CustomFields []CustomField `json:"custom_fields"`
+	AutoSync     AutoSync      `json:"auto_sync"`
+	DeleteGuard  DeleteGuard   `json:"delete_guard"`
+}
+
+const (
+	DefaultMaxDeletes       = 50
+	DefaultMaxDeletePercent = 20
+)
+
+// DeleteGuard holds back a sync that would delete more than MaxDeletes local
+// records, more than MaxDeletePercent percent of them, or all of them, which
+// usually means the Cloudflare listing came back empty or truncated.
+type DeleteGuard struct {
+	Disabled bool `json:"disabled"`
+	// MaxDeletes of 0 means DefaultMaxDeletes.
+	MaxDeletes int `json:"max_deletes"`
+	// MaxDeletePercent of 0 means DefaultMaxDeletePercent.
+	MaxDeletePercent int `json:"max_delete_percent"`
+}
+
+func (g DeleteGuard) Validate() error {
+	if g.MaxDeletes < 0 {
+		return fmt.Errorf("delete guard max_deletes cannot be negative")
+	}
+	if g.MaxDeletePercent < 0 || g.MaxDeletePercent > 100 {
+		return fmt.Errorf("delete guard max_delete_percent must be between 0 and 100")
+	}
+	return nil
+}
+
+func (g DeleteGuard) EffectiveMaxDeletes() int {
+	if g.MaxDeletes == 0 {
+		return DefaultMaxDeletes
+	}
+	return g.MaxDeletes
+}
+
+func (g DeleteGuard) EffectiveMaxDeletePercent() int {
+	if g.MaxDeletePercent == 0 {
+		return DefaultMaxDeletePercent
+	}
+	return g.MaxDeletePercent
+}
+
+// MinAutoSyncInterval keeps scheduled syncs from exhausting the API rate limit.
+const MinAutoSyncInterval = 60
+
+type AutoSync struct {
+	// IntervalSeconds of 0 turns scheduled syncing off.
+	IntervalSeconds int `json:"interval_seconds"`
+	// JitterSeconds adds up to this many seconds to every interval so several
+	// running copies do not sync in lockstep.
+	JitterSeconds int `json:"jitter_seconds"`
+}
 
-	CloudflareAPIToken string `json:"cloudflare_api_token"`
-	AccountID          string `json:"account_id"`
-	NamespaceID        string `json:"namespace_id"`
-	Domain             string `json:"domain"`
+func (s AutoSync) Validate() error {
+	if s.IntervalSeconds != 0 && s.IntervalSeconds < MinAutoSyncInterval {
+		return fmt.Errorf("auto sync interval_seconds must be 0 or at least %d", MinAutoSyncInterval)
+	}
+	if s.JitterSeconds < 0 {
+		return fmt.Errorf("auto sync jitter_seconds cannot be negative")
+	}
+	return nil
+}
+
+const (
+	CustomFieldString  = "string"
+	CustomFieldNumber  = "number"
+	CustomFieldBoolean = "boolean"
+)
+
+type CustomField struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+}
+
+// MaxKeyBytes is the Cloudflare Workers KV limit on key length.
+const MaxKeyBytes = 512
+
+type KeyPolicy struct {
+	// RequireUUIDv4 applies to the part of the key after Prefix.
+	RequireUUIDv4 bool   `json:"require_uuid_v4"`
+	Pattern       string `json:"pattern"`
+	Prefix        string `json:"prefix"`
+	MaxBytes      int    `json:"max_bytes"`
+}
+
+func (p KeyPolicy) Validate() error {
+	if p.MaxBytes < 0 || p.MaxBytes > MaxKeyBytes {
+		return fmt.Errorf("key policy max_bytes must be between 0 and %d", MaxKeyBytes)
+	}
+	if len(p.Prefix) >= p.EffectiveMaxBytes() {
+		return fmt.Errorf("key policy prefix %q leaves no room for a key", p.Prefix)
+	}
+	if p.Pattern != "" {
+		if _, err := regexp.Compile(p.Pattern); err != nil {
+			return fmt.Errorf("key policy pattern %q: %w", p.Pattern, err)
+		}
+	}
+	return nil
+}
+
+func validateCustomFields(fields []CustomField) error {
+	seen := make(map[string]struct{}, len(fields))
+
+	for _, field := range fields {
+		if field.Name == "" {
+			return fmt.Errorf("custom field name cannot be empty")
+		}
+		if models.IsMetadataField(field.Name) {
+			return fmt.Errorf("custom field %q conflicts with a built-in metadata field", field.Name)
+		}
+		if _, ok := seen[field.Name]; ok {
+			return fmt.Errorf("custom field %q is defined more than once", field.Name)
+		}
+		seen[field.Name] = struct{}{}
+
+		switch field.Type {
+		case CustomFieldString, CustomFieldNumber, CustomFieldBoolean:
+		default:
+			return fmt.Errorf("custom field %q has unsupported type %q", field.Name, field.Type)
+		}
+	}
+
+	return nil
+}
+
+func (c Config) validate() error {
+	if err := c.KeyPolicy.Validate(); err != nil {
+		return err
+	}
+	if err := c.AutoSync.Validate(); err != nil {
+		return err
+	}
+	if err := c.DeleteGuard.Validate(); err != nil {
+		return err
+	}
+	if c.SyncPrefix != "" && c.KeyPolicy.Prefix != "" && !strings.HasPrefix(c.KeyPolicy.Prefix, c.SyncPrefix) {
+		return fmt.Errorf("key policy prefix %q must start with sync prefix %q", c.KeyPolicy.Prefix, c.SyncPrefix)
+	}
+	return validateCustomFields(c.CustomFields)
+}
+
+// Validate checks the local settings of c without contacting Cloudflare.
+func (c Config) Validate() error {
+	c.normalize()
+	if !c.IsComplete() {
+		return fmt.Errorf("config is incomplete")
+	}
+	return c.validate()
+}
+
+func (p KeyPolicy) EffectiveMaxBytes() int {
+	if p.MaxBytes == 0 {
+		return MaxKeyBytes
+	}
+	return p.MaxBytes
 }
 
 func (c *Config) normalize() {
@@ -25,9 +203,134 @@ func (c *Config) normalize() {
 		return
 	}
 	c.CloudflareAPIToken = strings.TrimSpace(c.CloudflareAPIToken)
+	c.CloudflareAPITokenRef = strings.TrimSpace(c.CloudflareAPITokenRef)
 	c.AccountID = strings.TrimSpace(c.AccountID)
 	c.NamespaceID = strings.TrimSpace(c.NamespaceID)
 	c.Domain = strings.TrimSpace(c.Domain)
+	c.SyncPrefix = strings.TrimSpace(c.SyncPrefix)
+	c.KeyPolicy.Pattern = strings.TrimSpace(c.KeyPolicy.Pattern)
+	c.KeyPolicy.Prefix = strings.TrimSpace(c.KeyPolicy.Prefix)
+	for i := range c.CustomFields {
+		c.CustomFields[i].Name = strings.TrimSpace(c.CustomFields[i].Name)
+		c.CustomFields[i].Type = strings.ToLower(strings.TrimSpace(c.CustomFields[i].Type))
+	}
+}
+
+// tokenCache holds api tokens already read from the secret store, keyed by
+// reference, so loading a config does not query the keyring or derive the
+// file backend key every time. Environment references are read directly.
+var tokenCache = struct {
+	sync.Mutex
+	tokens map[string]string
+}{tokens: map[string]string{}}
+
+func cachedToken(ref string) string {
+	tokenCache.Lock()
+	defer tokenCache.Unlock()
+	return tokenCache.tokens[ref]
+}
+
+func forgetToken(ref string) {
+	tokenCache.Lock()
+	defer tokenCache.Unlock()
+	delete(tokenCache.tokens, ref)
+}
+
+func (c *Config) resolveToken() error {
+	if c.CloudflareAPIToken != "" || c.CloudflareAPITokenRef == "" {
+		return nil
+	}
+
+	ref := c.CloudflareAPITokenRef
+	scheme, _, err := secrets.ParseRef(ref)
+	if err != nil {
+		return fmt.Errorf("resolve api token for profile %q: %w", c.Profile, err)
+	}
+
+	tokenCache.Lock()
+	defer tokenCache.Unlock()
+
+	if token, ok := tokenCache.tokens[ref]; ok {
+		c.CloudflareAPIToken = token
+		return nil
+	}
+
+	token, err := secrets.Default.Get(ref)
+	if err != nil {
+		return fmt.Errorf("resolve api token for profile %q: %w", c.Profile, err)
+	}
+
+	if scheme != secrets.SchemeEnv {
+		tokenCache.tokens[ref] = token
+	}
+
+	c.CloudflareAPIToken = token
+	return nil
+}
+
+// ErrPlaintextToken is wrapped when no secret store accepted an api token, so
+// it was written to config.json in plaintext. The rest of the config is still
+// saved.
+var ErrPlaintextToken = errors.New("api token left in plaintext")
+
+// storeToken moves a plaintext token into the secret store, trying the OS
+// keyring and then the encrypted file when the profile has no reference yet.
+// If no backend accepts it, the token stays in plaintext and an error wrapping
+// ErrPlaintextToken says why. A profile that reads its token from an
+// environment variable cannot be given a new one.
+func (c *Config) storeToken(profile string) error {
+	if c.CloudflareAPIToken == "" {
+		return nil
+	}
+
+	var failures []error
+
+	candidates := []string{c.CloudflareAPITokenRef}
+	if c.CloudflareAPITokenRef == "" {
+		candidates = []string{
+			secrets.Ref(secrets.SchemeKeyring, profile),
+			secrets.Ref(secrets.SchemeFile, profile),
+		}
+	}
+
+	for _, ref := range candidates {
+		scheme, _, err := secrets.ParseRef(ref)
+		if err != nil || !secrets.Default.HasBackend(scheme) {
+			continue
+		}
+
+		// environment references are read-only; the variable supplies the token
+		if scheme == secrets.SchemeEnv {
+			current, err := secrets.Default.Get(ref)
+			if err != nil || current != c.CloudflareAPIToken {
+				return fmt.Errorf("profile %q reads its api token from %s; change the variable instead", profile, ref)
+			}
+			c.CloudflareAPIToken = ""
+			return nil
+		}
+
+		// a token resolved on load is saved back unchanged
+		if cachedToken(ref) == c.CloudflareAPIToken {
+			c.CloudflareAPIToken = ""
+			c.CloudflareAPITokenRef = ref
+			return nil
+		}
+
+		forgetToken(ref)
+		if err := secrets.Default.Set(ref, c.CloudflareAPIToken); err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", scheme, err))
+			continue
+		}
+
+		c.CloudflareAPIToken = ""
+		c.CloudflareAPITokenRef = ref
+		return nil
+	}
+
+	if len(failures) == 0 {
+		return fmt.Errorf("profile %q: %w: no secret store is available", profile, ErrPlaintextToken)
+	}
+	return fmt.Errorf("profile %q: %w: %w", profile, ErrPlaintextToken, errors.Join(failures...))
 }
 
 func (c Config) IsComplete() bool {
@@ -37,52 +340,69 @@ func (c Config) IsComplete() bool {
 		strings.TrimSpace(c.Domain) != ""
 }
 
+// LoadConfig returns the settings of the active profile, with any CDNMANAGER_*
+// environment overrides applied.
 func LoadConfig(configPath string) (*Config, error) {
-	data, err := os.ReadFile(configPath)
+	file, err := readFile(configPath)
 	if err != nil {
-		return nil, fmt.Errorf("read config %q: %w", configPath, err)
+		return nil, err
 	}
 
-	dec := json.NewDecoder(bytes.NewReader(data))
-	dec.DisallowUnknownFields()
-
-	var cfg Config
-	if err := dec.Decode(&cfg); err != nil {
-		return nil, fmt.Errorf("decode config %q: %w", configPath, err)
-	}
-	if dec.More() {
-		return nil, fmt.Errorf("decode config %q: multiple JSON objects", configPath)
+	if _, ok := file.Profiles[file.ActiveProfile]; !ok {
+		return nil, fmt.Errorf("config %q: active profile %q does not exist", configPath, file.ActiveProfile)
 	}
 
-	// handle missing version (older files or manual edits)
-	if cfg.Version == 0 {
-		cfg.Version = CurrentVersion
-	}
-	if cfg.Version > CurrentVersion {
-		return nil, fmt.Errorf("unsupported config version %d", cfg.Version)
+	cfg, err := file.load(file.ActiveProfile)
+	if err != nil {
+		return nil, fmt.Errorf("config %q: %w", configPath, err)
 	}
 
-	cfg.normalize()
-
-	return &cfg, nil
+	return cfg, nil
 }
 
+// SaveConfig stores cfg as the profile named by cfg.Profile, or as the active
+// profile when cfg.Profile is empty. A missing config file is created with cfg
+// as its default profile. Fields overridden by the environment keep their
+// stored values. An error wrapping ErrPlaintextToken means cfg was saved with
+// its api token in plaintext.
 func SaveConfig(configPath string, cfg Config) error {
-	cfg.Version = CurrentVersion
-	cfg.normalize()
+	file, err := readFile(configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		file = newFile()
+	} else if err != nil {
+		return err
+	}
 
-	if err := os.MkdirAll(filepath.Dir(configPath), 0o755); err != nil {
-		return fmt.Errorf("create config directory for %q: %w", configPath, err)
+	name := cfg.Profile
+	if name == "" {
+		name = file.ActiveProfile
 	}
 
-	data, err := json.MarshalIndent(cfg, "", "  ")
-	if err != nil {
-		return fmt.Errorf("marshal config %q: %w", configPath, err)
+	if stored, ok := file.Profiles[name]; ok && name == file.ActiveProfile {
+		cfg.keepStoredValues(stored)
 	}
 
-	if err := os.WriteFile(configPath, data, 0o600); err != nil {
-		return fmt.Errorf("write config %q: %w", configPath, err)
+	file.Profiles[name] = cfg
+
+	return writeFile(configPath, file)
+}
+
+// SaveSetup stores the credentials and domain of cfg in the active profile,
+// keeping every other stored setting. A missing config file is created with
+// cfg as its default profile.
+func SaveSetup(configPath string, cfg Config) error {
+	file, err := readFile(configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return SaveConfig(configPath, cfg)
+	} else if err != nil {
+		return err
 	}
 
-	return nil
+	merged := file.Profiles[file.ActiveProfile]
+	merged.CloudflareAPIToken = cfg.CloudflareAPIToken
+	merged.AccountID = cfg.AccountID
+	merged.NamespaceID = cfg.NamespaceID
+	merged.Domain = cfg.Domain
+
+	return SaveConfig(configPath, merged)
 }
@@ -0,0 +1,208 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"cdnmanager/pkg/secrets"
+)
+
+// memoryBackend stands in for the OS keyring and counts reads.
+type memoryBackend struct {
+	lock    sync.Mutex
+	secrets map[string]string
+	gets    int
+}
+
+func (b *memoryBackend) Get(key string) (string, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.gets++
+	secret, ok := b.secrets[key]
+	if !ok {
+		return "", secrets.ErrNotFound
+	}
+	return secret, nil
+}
+
+func (b *memoryBackend) Set(key string, secret string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.secrets[key] = secret
+	return nil
+}
+
+func (b *memoryBackend) Delete(key string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	delete(b.secrets, key)
+	return nil
+}
+
+func useMemoryKeyring(t *testing.T) *memoryBackend {
+	t.Helper()
+
+	backend := &memoryBackend{secrets: map[string]string{}}
+	secrets.Default.Register(secrets.SchemeKeyring, backend)
+	t.Cleanup(func() {
+		secrets.Default.Register(secrets.SchemeKeyring, secrets.KeyringBackend{Service: "cdnmanager"})
+		tokenCache.Lock()
+		tokenCache.tokens = map[string]string{}
+		tokenCache.Unlock()
+	})
+
+	return backend
+}
+
+func completeConfig() Config {
+	return Config{
+		CloudflareAPIToken: "token-one",
+		AccountID:          "account",
+		NamespaceID:        "namespace",
+		Domain:             "cdn.example.com",
+	}
+}
+
+func TestTokenIsResolvedOnceAndRefreshedOnSave(t *testing.T) {
+	backend := useMemoryKeyring(t)
+	configPath := filepath.Join(t.TempDir(), "config.json")
+
+	if err := SaveConfig(configPath, completeConfig()); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		cfg, err := LoadConfig(configPath)
+		if err != nil {
+			t.Fatalf("load config: %v", err)
+		}
+		if cfg.CloudflareAPIToken != "token-one" {
+			t.Fatalf("token = %q, want %q", cfg.CloudflareAPIToken, "token-one")
+		}
+	}
+	if backend.gets != 1 {
+		t.Errorf("keyring read %d times, want 1", backend.gets)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	cfg.CloudflareAPIToken = "token-two"
+	if err := SaveConfig(configPath, *cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	cfg, err = LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.CloudflareAPIToken != "token-two" {
+		t.Errorf("token after save = %q, want %q", cfg.CloudflareAPIToken, "token-two")
+	}
+}
+
+func TestSaveConfigRejectsNewTokenForEnvReference(t *testing.T) {
+	useMemoryKeyring(t)
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	t.Setenv("TEST_CDNMANAGER_TOKEN", "env-token")
+
+	cfg := completeConfig()
+	cfg.CloudflareAPIToken = ""
+	cfg.CloudflareAPITokenRef = secrets.Ref(secrets.SchemeEnv, "TEST_CDNMANAGER_TOKEN")
+	if err := SaveConfig(configPath, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	loaded, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if loaded.CloudflareAPIToken != "env-token" {
+		t.Fatalf("token = %q, want %q", loaded.CloudflareAPIToken, "env-token")
+	}
+
+	// saving the resolved token back is not a change
+	if err := SaveConfig(configPath, *loaded); err != nil {
+		t.Fatalf("save unchanged config: %v", err)
+	}
+
+	loaded.CloudflareAPIToken = "new-token"
+	if err := SaveConfig(configPath, *loaded); err == nil {
+		t.Fatal("expected an error saving a new token for an env reference")
+	}
+}
+
+func TestSaveSetupKeepsOtherSettings(t *testing.T) {
+	useMemoryKeyring(t)
+	configPath := filepath.Join(t.TempDir(), "config.json")
+
+	cfg := completeConfig()
+	cfg.SyncPrefix = "assets/"
+	cfg.KeyPolicy.RequireUUIDv4 = true
+	cfg.DeleteGuard.MaxDeletes = 30
+	if err := SaveConfig(configPath, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	if err := SaveSetup(configPath, Config{
+		CloudflareAPIToken: "token-two",
+		AccountID:          "account-two",
+		NamespaceID:        "namespace-two",
+		Domain:             "cdn2.example.com",
+	}); err != nil {
+		t.Fatalf("save setup: %v", err)
+	}
+
+	loaded, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+
+	if loaded.CloudflareAPIToken != "token-two" || loaded.AccountID != "account-two" ||
+		loaded.NamespaceID != "namespace-two" || loaded.Domain != "cdn2.example.com" {
+		t.Errorf("setup fields not saved: %+v", loaded)
+	}
+	if loaded.SyncPrefix != "assets/" || !loaded.KeyPolicy.RequireUUIDv4 || loaded.DeleteGuard.MaxDeletes != 30 {
+		t.Errorf("other settings not kept: %+v", loaded)
+	}
+}
+
+type failingBackend struct{}
+
+func (failingBackend) Get(string) (string, error) { return "", secrets.ErrNotFound }
+func (failingBackend) Set(string, string) error   { return errors.New("keyring locked") }
+func (failingBackend) Delete(string) error        { return nil }
+
+func TestSaveConfigReportsPlaintextToken(t *testing.T) {
+	useMemoryKeyring(t)
+	secrets.Default.Register(secrets.SchemeKeyring, failingBackend{})
+	configPath := filepath.Join(t.TempDir(), "config.json")
+
+	err := SaveConfig(configPath, completeConfig())
+	if !errors.Is(err, ErrPlaintextToken) {
+		t.Fatalf("error = %v, want one wrapping ErrPlaintextToken", err)
+	}
+	if !strings.Contains(err.Error(), "keyring locked") {
+		t.Errorf("error %q does not say why the keyring failed", err)
+	}
+
+	loaded, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if loaded.CloudflareAPIToken != "token-one" || loaded.CloudflareAPITokenRef != "" {
+		t.Errorf("token = %q ref = %q, want the plaintext token", loaded.CloudflareAPIToken, loaded.CloudflareAPITokenRef)
+	}
+
+	// switching profiles does not fail over a token that is already in plaintext
+	if err := SetActiveProfile(configPath, DefaultProfile); err != nil {
+		t.Errorf("set active profile: %v", err)
+	}
+}
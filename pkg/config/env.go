@@ -0,0 +1,224 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+)
+
+// EnvHome names the environment variable that replaces the directory holding
+// config.json and the local databases.
+const EnvHome = "CDNMANAGER_HOME"
+
+// envOverride maps one CDNMANAGER_* environment variable onto a Config field.
+// Overrides apply to the active profile on load and are never written back to
+// config.json.
+type envOverride struct {
+	name string
+	set  func(c *Config, value string) error
+	keep func(dst *Config, src Config)
+}
+
+var envOverrides = []envOverride{
+	{
+		name: "CDNMANAGER_CLOUDFLARE_API_TOKEN",
+		set:  func(c *Config, v string) error { c.CloudflareAPIToken = v; return nil },
+		keep: func(dst *Config, src Config) {
+			dst.CloudflareAPIToken = src.CloudflareAPIToken
+			dst.CloudflareAPITokenRef = src.CloudflareAPITokenRef
+		},
+	},
+	{
+		name: "CDNMANAGER_ACCOUNT_ID",
+		set:  func(c *Config, v string) error { c.AccountID = v; return nil },
+		keep: func(dst *Config, src Config) { dst.AccountID = src.AccountID },
+	},
+	{
+		name: "CDNMANAGER_NAMESPACE_ID",
+		set:  func(c *Config, v string) error { c.NamespaceID = v; return nil },
+		keep: func(dst *Config, src Config) { dst.NamespaceID = src.NamespaceID },
+	},
+	{
+		name: "CDNMANAGER_DOMAIN",
+		set:  func(c *Config, v string) error { c.Domain = v; return nil },
+		keep: func(dst *Config, src Config) { dst.Domain = src.Domain },
+	},
+	{
+		name: "CDNMANAGER_SYNC_PREFIX",
+		set:  func(c *Config, v string) error { c.SyncPrefix = v; return nil },
+		keep: func(dst *Config, src Config) { dst.SyncPrefix = src.SyncPrefix },
+	},
+	{
+		name: "CDNMANAGER_KEY_POLICY_REQUIRE_UUID_V4",
+		set: func(c *Config, v string) error {
+			required, err := strconv.ParseBool(v)
+			if err != nil {
+				return err
+			}
+			c.KeyPolicy.RequireUUIDv4 = required
+			return nil
+		},
+		keep: func(dst *Config, src Config) { dst.KeyPolicy.RequireUUIDv4 = src.KeyPolicy.RequireUUIDv4 },
+	},
+	{
+		name: "CDNMANAGER_KEY_POLICY_PATTERN",
+		set:  func(c *Config, v string) error { c.KeyPolicy.Pattern = v; return nil },
+		keep: func(dst *Config, src Config) { dst.KeyPolicy.Pattern = src.KeyPolicy.Pattern },
+	},
+	{
+		name: "CDNMANAGER_KEY_POLICY_PREFIX",
+		set:  func(c *Config, v string) error { c.KeyPolicy.Prefix = v; return nil },
+		keep: func(dst *Config, src Config) { dst.KeyPolicy.Prefix = src.KeyPolicy.Prefix },
+	},
+	{
+		name: "CDNMANAGER_KEY_POLICY_MAX_BYTES",
+		set: func(c *Config, v string) error {
+			maxBytes, err := strconv.Atoi(v)
+			if err != nil {
+				return err
+			}
+			c.KeyPolicy.MaxBytes = maxBytes
+			return nil
+		},
+		keep: func(dst *Config, src Config) { dst.KeyPolicy.MaxBytes = src.KeyPolicy.MaxBytes },
+	},
+	{
+		// custom fields are given as the same JSON array used in config.json
+		name: "CDNMANAGER_CUSTOM_FIELDS",
+		set: func(c *Config, v string) error {
+			var fields []CustomField
+			if err := json.Unmarshal([]byte(v), &fields); err != nil {
+				return err
+			}
+			c.CustomFields = fields
+			return nil
+		},
+		keep: func(dst *Config, src Config) { dst.CustomFields = src.CustomFields },
+	},
+	{
+		name: "CDNMANAGER_AUTO_SYNC_INTERVAL_SECONDS",
+		set: func(c *Config, v string) error {
+			seconds, err := strconv.Atoi(v)
+			if err != nil {
+				return err
+			}
+			c.AutoSync.IntervalSeconds = seconds
+			return nil
+		},
+		keep: func(dst *Config, src Config) { dst.AutoSync.IntervalSeconds = src.AutoSync.IntervalSeconds },
+	},
+	{
+		name: "CDNMANAGER_AUTO_SYNC_JITTER_SECONDS",
+		set: func(c *Config, v string) error {
+			seconds, err := strconv.Atoi(v)
+			if err != nil {
+				return err
+			}
+			c.AutoSync.JitterSeconds = seconds
+			return nil
+		},
+		keep: func(dst *Config, src Config) { dst.AutoSync.JitterSeconds = src.AutoSync.JitterSeconds },
+	},
+	{
+		name: "CDNMANAGER_DELETE_GUARD_DISABLED",
+		set: func(c *Config, v string) error {
+			disabled, err := strconv.ParseBool(v)
+			if err != nil {
+				return err
+			}
+			c.DeleteGuard.Disabled = disabled
+			return nil
+		},
+		keep: func(dst *Config, src Config) { dst.DeleteGuard.Disabled = src.DeleteGuard.Disabled },
+	},
+	{
+		name: "CDNMANAGER_DELETE_GUARD_MAX_DELETES",
+		set: func(c *Config, v string) error {
+			maxDeletes, err := strconv.Atoi(v)
+			if err != nil {
+				return err
+			}
+			c.DeleteGuard.MaxDeletes = maxDeletes
+			return nil
+		},
+		keep: func(dst *Config, src Config) { dst.DeleteGuard.MaxDeletes = src.DeleteGuard.MaxDeletes },
+	},
+	{
+		name: "CDNMANAGER_DELETE_GUARD_MAX_DELETE_PERCENT",
+		set: func(c *Config, v string) error {
+			percent, err := strconv.Atoi(v)
+			if err != nil {
+				return err
+			}
+			c.DeleteGuard.MaxDeletePercent = percent
+			return nil
+		},
+		keep: func(dst *Config, src Config) { dst.DeleteGuard.MaxDeletePercent = src.DeleteGuard.MaxDeletePercent },
+	},
+}
+
+func lookupEnv(name string) (string, bool) {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return "", false
+	}
+	return value, true
+}
+
+func (c *Config) applyEnv() error {
+	for _, override := range envOverrides {
+		value, ok := lookupEnv(override.name)
+		if !ok {
+			continue
+		}
+		if err := override.set(c, value); err != nil {
+			return fmt.Errorf("environment variable %s: %w", override.name, err)
+		}
+	}
+
+	c.normalize()
+	return c.validate()
+}
+
+// keepStoredValues replaces every field that is overridden by the environment
+// with its value from stored, so overrides do not leak into config.json.
+func (c *Config) keepStoredValues(stored Config) {
+	for _, override := range envOverrides {
+		if _, ok := lookupEnv(override.name); ok {
+			override.keep(c, stored)
+		}
+	}
+}
+
+// EnvOverrides returns the names of the CDNMANAGER_* variables that are
+// currently overriding config.json.
+func EnvOverrides() []string {
+	var names []string
+	for _, override := range envOverrides {
+		if _, ok := lookupEnv(override.name); ok {
+			names = append(names, override.name)
+		}
+	}
+	return names
+}
+
+// EnvConflicts returns the CDNMANAGER_* variables that override a field
+// differing between updated and current. SaveConfig would keep the stored
+// value of such a field, so the change would never take effect.
+func EnvConflicts(current Config, updated Config) []string {
+	var names []string
+	for _, override := range envOverrides {
+		if _, ok := lookupEnv(override.name); !ok {
+			continue
+		}
+
+		restored := updated
+		override.keep(&restored, current)
+		if !reflect.DeepEqual(restored, updated) {
+			names = append(names, override.name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,252 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnvOverridePrecedence(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  func(c *Config)
+		env   map[string]string
+		check func(t *testing.T, c *Config)
+	}{
+		{
+			name: "default when neither file nor env set",
+			check: func(t *testing.T, c *Config) {
+				if got := c.DeleteGuard.EffectiveMaxDeletes(); got != DefaultMaxDeletes {
+					t.Errorf("max deletes = %d, want default %d", got, DefaultMaxDeletes)
+				}
+			},
+		},
+		{
+			name: "file over default",
+			file: func(c *Config) { c.DeleteGuard.MaxDeletes = 30 },
+			check: func(t *testing.T, c *Config) {
+				if got := c.DeleteGuard.EffectiveMaxDeletes(); got != 30 {
+					t.Errorf("max deletes = %d, want 30", got)
+				}
+			},
+		},
+		{
+			name: "env over file",
+			file: func(c *Config) { c.DeleteGuard.MaxDeletes = 30 },
+			env:  map[string]string{"CDNMANAGER_DELETE_GUARD_MAX_DELETES": "10"},
+			check: func(t *testing.T, c *Config) {
+				if got := c.DeleteGuard.EffectiveMaxDeletes(); got != 10 {
+					t.Errorf("max deletes = %d, want 10", got)
+				}
+			},
+		},
+		{
+			name: "env over default",
+			env:  map[string]string{"CDNMANAGER_DELETE_GUARD_MAX_DELETE_PERCENT": "5"},
+			check: func(t *testing.T, c *Config) {
+				if got := c.DeleteGuard.EffectiveMaxDeletePercent(); got != 5 {
+					t.Errorf("max delete percent = %d, want 5", got)
+				}
+			},
+		},
+		{
+			name: "empty env value is ignored",
+			file: func(c *Config) { c.Domain = "file.example.com" },
+			env:  map[string]string{"CDNMANAGER_DOMAIN": ""},
+			check: func(t *testing.T, c *Config) {
+				if c.Domain != "file.example.com" {
+					t.Errorf("domain = %q, want %q", c.Domain, "file.example.com")
+				}
+			},
+		},
+		{
+			name: "string and json values",
+			file: func(c *Config) { c.Domain = "file.example.com" },
+			env: map[string]string{
+				"CDNMANAGER_DOMAIN":        "env.example.com",
+				"CDNMANAGER_CUSTOM_FIELDS": `[{"name":"owner","type":"string","required":true}]`,
+			},
+			check: func(t *testing.T, c *Config) {
+				if c.Domain != "env.example.com" {
+					t.Errorf("domain = %q, want %q", c.Domain, "env.example.com")
+				}
+				if len(c.CustomFields) != 1 || c.CustomFields[0].Name != "owner" || !c.CustomFields[0].Required {
+					t.Errorf("custom fields = %+v, want one required owner field", c.CustomFields)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useMemoryKeyring(t)
+			configPath := filepath.Join(t.TempDir(), "config.json")
+
+			cfg := completeConfig()
+			if tt.file != nil {
+				tt.file(&cfg)
+			}
+			if err := SaveConfig(configPath, cfg); err != nil {
+				t.Fatalf("save config: %v", err)
+			}
+
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			loaded, err := LoadConfig(configPath)
+			if err != nil {
+				t.Fatalf("load config: %v", err)
+			}
+			tt.check(t, loaded)
+		})
+	}
+}
+
+func TestEnvOverrideInvalidValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "CDNMANAGER_KEY_POLICY_REQUIRE_UUID_V4", value: "maybe"},
+		{name: "CDNMANAGER_KEY_POLICY_MAX_BYTES", value: "lots"},
+		{name: "CDNMANAGER_CUSTOM_FIELDS", value: "[{"},
+		{name: "CDNMANAGER_AUTO_SYNC_INTERVAL_SECONDS", value: "10"},
+		{name: "CDNMANAGER_DELETE_GUARD_MAX_DELETE_PERCENT", value: "150"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useMemoryKeyring(t)
+			configPath := filepath.Join(t.TempDir(), "config.json")
+
+			if err := SaveConfig(configPath, completeConfig()); err != nil {
+				t.Fatalf("save config: %v", err)
+			}
+
+			t.Setenv(tt.name, tt.value)
+
+			_, err := LoadConfig(configPath)
+			if err == nil {
+				t.Fatalf("expected an error for %s=%q", tt.name, tt.value)
+			}
+		})
+	}
+}
+
+func TestEnvOverrideParseErrorNamesVariable(t *testing.T) {
+	useMemoryKeyring(t)
+	configPath := filepath.Join(t.TempDir(), "config.json")
+
+	if err := SaveConfig(configPath, completeConfig()); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	t.Setenv("CDNMANAGER_DELETE_GUARD_MAX_DELETES", "many")
+
+	_, err := LoadConfig(configPath)
+	if err == nil || !strings.Contains(err.Error(), "CDNMANAGER_DELETE_GUARD_MAX_DELETES") {
+		t.Fatalf("error = %v, want one naming CDNMANAGER_DELETE_GUARD_MAX_DELETES", err)
+	}
+}
+
+func TestSaveConfigKeepsEnvValuesOutOfFile(t *testing.T) {
+	keyring := useMemoryKeyring(t)
+	configPath := filepath.Join(t.TempDir(), "config.json")
+
+	cfg := completeConfig()
+	cfg.DeleteGuard.MaxDeletes = 30
+	if err := SaveConfig(configPath, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	t.Setenv("CDNMANAGER_CLOUDFLARE_API_TOKEN", "env-token")
+	t.Setenv("CDNMANAGER_DOMAIN", "env.example.com")
+	t.Setenv("CDNMANAGER_DELETE_GUARD_MAX_DELETES", "10")
+
+	loaded, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if loaded.CloudflareAPIToken != "env-token" || loaded.Domain != "env.example.com" {
+		t.Fatalf("env overrides not applied: %+v", loaded)
+	}
+
+	loaded.SyncPrefix = "assets/"
+	if err := SaveConfig(configPath, *loaded); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+
+	var file configFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatalf("decode config: %v", err)
+	}
+	stored := file.Profiles[DefaultProfile]
+
+	if stored.SyncPrefix != "assets/" {
+		t.Errorf("sync prefix = %q, want the edited %q", stored.SyncPrefix, "assets/")
+	}
+	if stored.Domain != "cdn.example.com" {
+		t.Errorf("domain = %q, want the file value %q", stored.Domain, "cdn.example.com")
+	}
+	if stored.DeleteGuard.MaxDeletes != 30 {
+		t.Errorf("max deletes = %d, want the file value 30", stored.DeleteGuard.MaxDeletes)
+	}
+	if stored.CloudflareAPIToken != "" {
+		t.Errorf("plaintext token %q written to config", stored.CloudflareAPIToken)
+	}
+	if token := keyring.secrets[DefaultProfile]; token != "token-one" {
+		t.Errorf("stored token = %q, want the original %q", token, "token-one")
+	}
+	if strings.Contains(string(data), "env-token") || strings.Contains(string(data), "env.example.com") {
+		t.Errorf("environment values leaked into config:\n%s", data)
+	}
+}
+
+func TestEnvConflicts(t *testing.T) {
+	t.Setenv("CDNMANAGER_CLOUDFLARE_API_TOKEN", "env-token")
+	t.Setenv("CDNMANAGER_KEY_POLICY_PATTERN", "^a")
+
+	current := completeConfig()
+	current.CloudflareAPIToken = "env-token"
+	current.KeyPolicy.Pattern = "^a"
+
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		want   []string
+	}{
+		{name: "unchanged", change: func(c *Config) {}},
+		{name: "field without override", change: func(c *Config) { c.Domain = "cdn2.example.com" }},
+		{
+			name:   "overridden token",
+			change: func(c *Config) { c.CloudflareAPIToken = "new-token" },
+			want:   []string{"CDNMANAGER_CLOUDFLARE_API_TOKEN"},
+		},
+		{
+			name:   "overridden nested field",
+			change: func(c *Config) { c.KeyPolicy.Pattern = "^b" },
+			want:   []string{"CDNMANAGER_KEY_POLICY_PATTERN"},
+		},
+		{name: "sibling of overridden field", change: func(c *Config) { c.KeyPolicy.MaxBytes = 100 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := current
+			tt.change(&updated)
+
+			got := EnvConflicts(current, updated)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("conflicts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package config
+
+import "cdnmanager/pkg/secrets"
+
+// Patch holds the config fields to change; nil fields are left as they are.
+type Patch struct {
+	CloudflareAPIToken *string        `json:"cloudflare_api_token,omitempty"`
+	AccountID          *string        `json:"account_id,omitempty"`
+	NamespaceID        *string        `json:"namespace_id,omitempty"`
+	Domain             *string        `json:"domain,omitempty"`
+	SyncPrefix         *string        `json:"sync_prefix,omitempty"`
+	KeyPolicy          *KeyPolicy     `json:"key_policy,omitempty"`
+	CustomFields       *[]CustomField `json:"custom_fields,omitempty"`
+	AutoSync           *AutoSync      `json:"auto_sync,omitempty"`
+	DeleteGuard        *DeleteGuard   `json:"delete_guard,omitempty"`
+}
+
+func (p Patch) IsEmpty() bool {
+	return p.CloudflareAPIToken == nil &&
+		p.AccountID == nil &&
+		p.NamespaceID == nil &&
+		p.Domain == nil &&
+		p.SyncPrefix == nil &&
+		p.KeyPolicy == nil &&
+		p.CustomFields == nil &&
+		p.AutoSync == nil &&
+		p.DeleteGuard == nil
+}
+
+func (p Patch) Apply(c Config) Config {
+	if p.CloudflareAPIToken != nil {
+		c.CloudflareAPIToken = *p.CloudflareAPIToken
+	}
+	if p.AccountID != nil {
+		c.AccountID = *p.AccountID
+	}
+	if p.NamespaceID != nil {
+		c.NamespaceID = *p.NamespaceID
+	}
+	if p.Domain != nil {
+		c.Domain = *p.Domain
+	}
+	if p.SyncPrefix != nil {
+		c.SyncPrefix = *p.SyncPrefix
+	}
+	if p.KeyPolicy != nil {
+		c.KeyPolicy = *p.KeyPolicy
+	}
+	if p.CustomFields != nil {
+		c.CustomFields = *p.CustomFields
+	}
+	if p.AutoSync != nil {
+		c.AutoSync = *p.AutoSync
+	}
+	if p.DeleteGuard != nil {
+		c.DeleteGuard = *p.DeleteGuard
+	}
+	c.normalize()
+	return c
+}
+
+// CredentialsChanged reports whether c has to be verified against Cloudflare
+// again because its token, account or namespace differ from old.
+func (c Config) CredentialsChanged(old Config) bool {
+	return c.CloudflareAPIToken != old.CloudflareAPIToken ||
+		c.AccountID != old.AccountID ||
+		c.NamespaceID != old.NamespaceID
+}
+
+// SessionChanged reports whether a Cloudflare session built from old can no
+// longer be used for c. Sessions hold the credentials, domain and sync prefix.
+func (c Config) SessionChanged(old Config) bool {
+	return c.CredentialsChanged(old) ||
+		c.Domain != old.Domain ||
+		c.SyncPrefix != old.SyncPrefix
+}
+
+// TokenFromEnv reports whether the api token is read from an environment
+// variable, in which case it cannot be changed through the config.
+func (c Config) TokenFromEnv() bool {
+	scheme, _, err := secrets.ParseRef(c.CloudflareAPITokenRef)
+	return err == nil && scheme == secrets.SchemeEnv
+}
+
+// Redacted returns a copy of c that is safe to show, with all but the last
+// four characters of the api token masked.
+func (c Config) Redacted() Config {
+	token := c.CloudflareAPIToken
+	if len(token) > 4 {
+		c.CloudflareAPIToken = "********" + token[len(token)-4:]
+	} else if token != "" {
+		c.CloudflareAPIToken = "********"
+	}
+	return c
+}
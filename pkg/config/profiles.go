@@ -0,0 +1,319 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"cdnmanager/pkg/secrets"
+)
+
+const DefaultProfile = "default"
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+type configFile struct {
+	Version       int               `json:"version"`
+	ActiveProfile string            `json:"active_profile"`
+	Profiles      map[string]Config `json:"profiles"`
+}
+
+func newFile() *configFile {
+	return &configFile{
+		Version:       CurrentVersion,
+		ActiveProfile: DefaultProfile,
+		Profiles:      map[string]Config{},
+	}
+}
+
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("profile name %q must be 1-64 letters, digits, underscores or hyphens", name)
+	}
+	return nil
+}
+
+func readFile(configPath string) (*configFile, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("read config %q: %w", configPath, err)
+	}
+
+	var probe struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("decode config %q: %w", configPath, err)
+	}
+
+	var file *configFile
+
+	switch {
+	// handle missing version (older files or manual edits)
+	case probe.Version <= 1:
+		var cfg Config
+		if err := decodeStrict(data, &cfg); err != nil {
+			return nil, fmt.Errorf("decode config %q: %w", configPath, err)
+		}
+		cfg.Version = 0
+
+		file = newFile()
+		file.Profiles[DefaultProfile] = cfg
+	case probe.Version == CurrentVersion:
+		file = &configFile{}
+		if err := decodeStrict(data, file); err != nil {
+			return nil, fmt.Errorf("decode config %q: %w", configPath, err)
+		}
+		if file.Profiles == nil {
+			file.Profiles = map[string]Config{}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config version %d", probe.Version)
+	}
+
+	if err := file.normalize(); err != nil {
+		return nil, fmt.Errorf("config %q: %w", configPath, err)
+	}
+
+	return file, nil
+}
+
+func decodeStrict(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return fmt.Errorf("multiple JSON objects")
+	}
+	return nil
+}
+
+func (f *configFile) normalize() error {
+	if f.ActiveProfile == "" {
+		f.ActiveProfile = DefaultProfile
+	}
+
+	for name, cfg := range f.Profiles {
+		if err := ValidateProfileName(name); err != nil {
+			return err
+		}
+
+		cfg.Version = 0
+		cfg.Profile = ""
+		cfg.normalize()
+
+		if err := cfg.validate(); err != nil {
+			return fmt.Errorf("profile %q: %w", name, err)
+		}
+		f.Profiles[name] = cfg
+	}
+
+	return nil
+}
+
+func writeFile(configPath string, file *configFile) error {
+	file.Version = CurrentVersion
+
+	if err := file.normalize(); err != nil {
+		return fmt.Errorf("config %q: %w", configPath, err)
+	}
+	if _, ok := file.Profiles[file.ActiveProfile]; !ok {
+		return fmt.Errorf("config %q: active profile %q does not exist", configPath, file.ActiveProfile)
+	}
+
+	// tokens left in plaintext are reported once the file is written
+	var plaintext []error
+	for name, cfg := range file.Profiles {
+		if err := cfg.storeToken(name); errors.Is(err, ErrPlaintextToken) {
+			plaintext = append(plaintext, err)
+		} else if err != nil {
+			return err
+		}
+		file.Profiles[name] = cfg
+	}
+
+	if err := os.MkdirAll(filepath.Dir(configPath), 0o755); err != nil {
+		return fmt.Errorf("create config directory for %q: %w", configPath, err)
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal config %q: %w", configPath, err)
+	}
+
+	if err := os.WriteFile(configPath, data, 0o600); err != nil {
+		return fmt.Errorf("write config %q: %w", configPath, err)
+	}
+
+	return errors.Join(plaintext...)
+}
+
+// MigrateConfig rewrites an older config file in the current format, moving
+// a version 1 config into the default profile and plaintext api tokens into
+// the secret store. An error wrapping ErrPlaintextToken means the file was
+// rewritten but some tokens are still in plaintext.
+func MigrateConfig(configPath string) error {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("read config %q: %w", configPath, err)
+	}
+
+	var probe struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return fmt.Errorf("decode config %q: %w", configPath, err)
+	}
+	file, err := readFile(configPath)
+	if err != nil {
+		return err
+	}
+
+	if probe.Version == CurrentVersion && !file.hasPlaintextTokens() {
+		return nil
+	}
+
+	return writeFile(configPath, file)
+}
+
+func (f *configFile) hasPlaintextTokens() bool {
+	for _, cfg := range f.Profiles {
+		if cfg.CloudflareAPIToken != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func ListProfiles(configPath string) (profiles []string, active string, err error) {
+	file, err := readFile(configPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	profiles = make([]string, 0, len(file.Profiles))
+	for name := range file.Profiles {
+		profiles = append(profiles, name)
+	}
+	sort.Strings(profiles)
+
+	return profiles, file.ActiveProfile, nil
+}
+
+func LoadProfile(configPath string, name string) (*Config, error) {
+	file, err := readFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := file.Profiles[name]; !ok {
+		return nil, fmt.Errorf("profile %q does not exist", name)
+	}
+
+	return file.load(name)
+}
+
+// load returns the named profile with its api token resolved. Environment
+// overrides only apply to the active profile.
+func (f *configFile) load(name string) (*Config, error) {
+	cfg := f.Profiles[name]
+	cfg.Profile = name
+
+	if name == f.ActiveProfile {
+		if err := cfg.applyEnv(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.resolveToken(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func ActiveProfile(configPath string) (string, error) {
+	file, err := readFile(configPath)
+	if err != nil {
+		return "", err
+	}
+	return file.ActiveProfile, nil
+}
+
+func SetActiveProfile(configPath string, name string) error {
+	file, err := readFile(configPath)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := file.Profiles[name]; !ok {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+
+	file.ActiveProfile = name
+
+	// tokens already in plaintext were reported when they were saved
+	if err := writeFile(configPath, file); err != nil && !errors.Is(err, ErrPlaintextToken) {
+		return err
+	}
+
+	return nil
+}
+
+func AddProfile(configPath string, name string, cfg Config) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+
+	file, err := readFile(configPath)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := file.Profiles[name]; ok {
+		return fmt.Errorf("profile %q already exists", name)
+	}
+
+	file.Profiles[name] = cfg
+
+	return writeFile(configPath, file)
+}
+
+func RemoveProfile(configPath string, name string) error {
+	file, err := readFile(configPath)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := file.Profiles[name]; !ok {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+	if name == file.ActiveProfile {
+		return fmt.Errorf("cannot remove the active profile %q", name)
+	}
+
+	ref := file.Profiles[name].CloudflareAPITokenRef
+	delete(file.Profiles, name)
+
+	if err := writeFile(configPath, file); err != nil && !errors.Is(err, ErrPlaintextToken) {
+		return err
+	}
+
+	if ref != "" {
+		forgetToken(ref)
+		if err := secrets.Default.Delete(ref); err != nil &&
+			!errors.Is(err, secrets.ErrNotFound) && !errors.Is(err, secrets.ErrReadOnly) {
+			return fmt.Errorf("profile removed but deleting its api token failed: %w", err)
+		}
+	}
+
+	return nil
+}
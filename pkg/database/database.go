@@ -2,6 +2,7 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -18,6 +19,9 @@ type Database struct {
 }
 
 func (cdb *Database) GetFileName() string {
+	cdb.lock.Lock()
+	defer cdb.lock.Unlock()
+
 	return cdb.dbName
 }
 
@@ -39,6 +43,45 @@ func NewDatabase(dbName string) (*Database, error) {
 }
 
 func NewDatabaseFromSchema(dbName string, schema []byte) (*Database, error) {
+	db, err := openWithSchema(dbName, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Database{
+		dbName: dbName,
+		db:     db,
+	}, nil
+}
+
+// ErrClosePrevious is wrapped when SwitchFile switched to the new file but
+// could not close the previous one.
+var ErrClosePrevious = errors.New("close previous database")
+
+// SwitchFile closes the current database and continues with dbName, applying
+// schema to it. The Database value itself stays the same so existing
+// references, such as frontend bindings, follow the switch. Once dbName is
+// open the switch stands, even when the error wraps ErrClosePrevious.
+func (cdb *Database) SwitchFile(dbName string, schema []byte) error {
+	db, err := openWithSchema(dbName, schema)
+	if err != nil {
+		return err
+	}
+
+	cdb.lock.Lock()
+	previous := cdb.db
+	cdb.db = db
+	cdb.dbName = dbName
+	cdb.lock.Unlock()
+
+	if err := previous.Close(); err != nil {
+		return fmt.Errorf("%w: %w", ErrClosePrevious, err)
+	}
+
+	return nil
+}
+
+func openWithSchema(dbName string, schema []byte) (*sql.DB, error) {
 	db, err := sql.Open("sqlite3", dbName)
 	if err != nil {
 		return nil, fmt.Errorf("open sqlite database: %w", err)
@@ -54,10 +97,7 @@ func NewDatabaseFromSchema(dbName string, schema []byte) (*Database, error) {
 		return nil, fmt.Errorf("initialize schema: %w", err)
 	}
 
-	return &Database{
-		dbName: dbName,
-		db:     db,
-	}, nil
+	return db, nil
 }
 
 func (cdb *Database) CreateTable() error {
@@ -213,6 +253,103 @@ func (cdb *Database) GetAllEntries() ([]models.Entry, error) {
 	return entries, nil
 }
 
+type Query struct {
+	NamePrefix     string `json:"name_prefix"`
+	ValueContains  string `json:"value_contains"`
+	MetadataName   string `json:"metadata_name"`
+	External       *bool  `json:"external"`
+	MimeType       string `json:"mimetype"`
+	Location       string `json:"location"`
+	CloudStorageID string `json:"cloud_storage_id"`
+}
+
+func (q Query) IsEmpty() bool {
+	return q.NamePrefix == "" &&
+		q.ValueContains == "" &&
+		q.MetadataName == "" &&
+		q.External == nil &&
+		q.MimeType == "" &&
+		q.Location == "" &&
+		q.CloudStorageID == ""
+}
+
+func (q Query) where() (string, []interface{}) {
+	clauses := make([]string, 0)
+	args := make([]interface{}, 0)
+
+	if q.NamePrefix != "" {
+		clauses = append(clauses, `substr(name, 1, length(?)) = ?`)
+		args = append(args, q.NamePrefix, q.NamePrefix)
+	}
+	if q.ValueContains != "" {
+		clauses = append(clauses, `instr(value, ?) > 0`)
+		args = append(args, q.ValueContains)
+	}
+	if q.MetadataName != "" {
+		clauses = append(clauses, `json_extract(metadata, '$.name') = ?`)
+		args = append(args, q.MetadataName)
+	}
+	if q.External != nil {
+		clauses = append(clauses, `coalesce(json_extract(metadata, '$.external'), 0) = ?`)
+		args = append(args, *q.External)
+	}
+	if q.MimeType != "" {
+		clauses = append(clauses, `json_extract(metadata, '$.mimetype') = ?`)
+		args = append(args, q.MimeType)
+	}
+	if q.Location != "" {
+		clauses = append(clauses, `json_extract(metadata, '$.location') = ?`)
+		args = append(args, q.Location)
+	}
+	if q.CloudStorageID != "" {
+		clauses = append(clauses, `json_extract(metadata, '$.cloud_storage_id') = ?`)
+		args = append(args, q.CloudStorageID)
+	}
+
+	if len(clauses) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(clauses, " AND "), args
+}
+
+func (cdb *Database) QueryEntries(q Query) ([]models.Entry, error) {
+	cdb.lock.Lock()
+	defer cdb.lock.Unlock()
+
+	where, args := q.where()
+
+	rows, err := cdb.db.Query(`SELECT name, value, metadata FROM records`+where+` ORDER BY name`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]models.Entry, 0)
+	for rows.Next() {
+		var name, valueStr, metadataStr string
+		if err := rows.Scan(&name, &valueStr, &metadataStr); err != nil {
+			return nil, fmt.Errorf("scan queried entry: %w", err)
+		}
+
+		metadata, err := models.MetadataFromJSONString(metadataStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse metadata for %q: %w", name, err)
+		}
+
+		entries = append(entries, models.Entry{
+			Name:     name,
+			Value:    valueStr,
+			Metadata: metadata,
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate queried entries: %w", err)
+	}
+
+	return entries, nil
+}
+
 func (cdb *Database) UpsertEntry(entry models.Entry) error {
 	cdb.lock.Lock()
 	defer cdb.lock.Unlock()
@@ -294,6 +431,42 @@ func (cdb *Database) UpsertEntries(entries []models.Entry) error {
 	return nil
 }
 
+func (cdb *Database) ReplaceEntry(oldName string, entry models.Entry) error {
+	cdb.lock.Lock()
+	defer cdb.lock.Unlock()
+
+	tx, err := cdb.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin replace transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM records WHERE name = ?`, oldName); err != nil {
+		return fmt.Errorf("delete name %q: %w", oldName, err)
+	}
+
+	metadata, err := entry.Metadata.ToJSONString()
+	if err != nil {
+		return fmt.Errorf("serialize metadata for %q: %w", entry.Name, err)
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO records (name, value, metadata)
+		VALUES (?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			value = excluded.value,
+			metadata = excluded.metadata
+	`, entry.Name, entry.Value, metadata); err != nil {
+		return fmt.Errorf("upsert entry %q: %w", entry.Name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit replace transaction: %w", err)
+	}
+
+	return nil
+}
+
 func (cdb *Database) DeleteName(key string) error {
 	cdb.lock.Lock()
 	defer cdb.lock.Unlock()
@@ -339,6 +512,91 @@ func (cdb *Database) DeleteEntries(entries []models.Entry) error {
 	return nil
 }
 
+func (cdb *Database) ReplaceLinkChecks(checks []models.LinkCheck) error {
+	cdb.lock.Lock()
+	defer cdb.lock.Unlock()
+
+	tx, err := cdb.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin link check transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM link_checks`); err != nil {
+		return fmt.Errorf("clear link checks: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO link_checks (name, url, status_code, final_url, content_type, mimetype_mismatch, error, checked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("prepare link check statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, check := range checks {
+		if _, err := stmt.Exec(
+			check.Name,
+			check.URL,
+			check.StatusCode,
+			check.FinalURL,
+			check.ContentType,
+			check.MimeTypeMismatch,
+			check.Error,
+			check.CheckedAt,
+		); err != nil {
+			return fmt.Errorf("insert link check %q: %w", check.Name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit link check transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (cdb *Database) GetBrokenLinks() ([]models.LinkCheck, error) {
+	cdb.lock.Lock()
+	defer cdb.lock.Unlock()
+
+	rows, err := cdb.db.Query(`
+		SELECT name, url, status_code, final_url, content_type, mimetype_mismatch, error, checked_at
+		FROM link_checks
+		WHERE error != '' OR status_code >= 400 OR mimetype_mismatch
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query broken links: %w", err)
+	}
+	defer rows.Close()
+
+	checks := make([]models.LinkCheck, 0)
+	for rows.Next() {
+		var check models.LinkCheck
+		if err := rows.Scan(
+			&check.Name,
+			&check.URL,
+			&check.StatusCode,
+			&check.FinalURL,
+			&check.ContentType,
+			&check.MimeTypeMismatch,
+			&check.Error,
+			&check.CheckedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan link check: %w", err)
+		}
+		checks = append(checks, check)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate broken links: %w", err)
+	}
+
+	return checks, nil
+}
+
 func (cdb *Database) Size() (int, error) {
 	cdb.lock.Lock()
 	defer cdb.lock.Unlock()
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"cdnmanager/pkg/models"
+)
+
+const (
+	DuplicateByValue          = "value"
+	DuplicateByMD5Checksum    = "md5Checksum"
+	DuplicateByCloudStorageID = "cloud_storage_id"
+	DuplicateByNormalizedURL  = "normalized_url"
+)
+
+type DuplicateGroup struct {
+	Kind  string   `json:"kind"`
+	Key   string   `json:"key"`
+	Names []string `json:"names"`
+}
+
+func (cdb *Database) FindDuplicates() ([]DuplicateGroup, error) {
+	entries, err := cdb.GetAllEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	byValue := make(map[string][]models.Entry)
+	byMD5 := make(map[string][]models.Entry)
+	byCloudStorageID := make(map[string][]models.Entry)
+	byURL := make(map[string][]models.Entry)
+
+	for _, entry := range entries {
+		if entry.Value != "" {
+			byValue[entry.Value] = append(byValue[entry.Value], entry)
+		}
+		if checksum := strings.ToLower(entry.Metadata.MD5Checksum); checksum != "" {
+			byMD5[checksum] = append(byMD5[checksum], entry)
+		}
+		if entry.Metadata.CloudStorageID != "" {
+			byCloudStorageID[entry.Metadata.CloudStorageID] = append(byCloudStorageID[entry.Metadata.CloudStorageID], entry)
+		}
+		if normalized, ok := NormalizeURL(entry.Value); ok {
+			byURL[normalized] = append(byURL[normalized], entry)
+		}
+	}
+
+	groups := make([]DuplicateGroup, 0)
+	groups = appendDuplicateGroups(groups, DuplicateByValue, byValue)
+	groups = appendDuplicateGroups(groups, DuplicateByMD5Checksum, byMD5)
+	groups = appendDuplicateGroups(groups, DuplicateByCloudStorageID, byCloudStorageID)
+
+	// only report URL groups that the exact value grouping did not already catch
+	for key, members := range byURL {
+		if sameValue(members) {
+			delete(byURL, key)
+		}
+	}
+	groups = appendDuplicateGroups(groups, DuplicateByNormalizedURL, byURL)
+
+	return groups, nil
+}
+
+// NormalizeURL reduces a URL to a form in which links that differ only by
+// scheme, host case, default port, trailing slash, fragment or query
+// parameter order compare equal.
+func NormalizeURL(raw string) (string, bool) {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || parsed.Host == "" {
+		return "", false
+	}
+
+	scheme := strings.ToLower(parsed.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return "", false
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	if port := parsed.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+
+	path := strings.TrimRight(parsed.EscapedPath(), "/")
+
+	query := parsed.Query()
+	for key := range query {
+		sort.Strings(query[key])
+	}
+
+	normalized := host + path
+	if encoded := query.Encode(); encoded != "" {
+		normalized += "?" + encoded
+	}
+
+	return normalized, true
+}
+
+func appendDuplicateGroups(groups []DuplicateGroup, kind string, grouped map[string][]models.Entry) []DuplicateGroup {
+	keys := make([]string, 0, len(grouped))
+	for key, members := range grouped {
+		if len(members) > 1 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		names := make([]string, 0, len(grouped[key]))
+		for _, entry := range grouped[key] {
+			names = append(names, entry.Name)
+		}
+		sort.Strings(names)
+
+		groups = append(groups, DuplicateGroup{Kind: kind, Key: key, Names: names})
+	}
+
+	return groups
+}
+
+func sameValue(entries []models.Entry) bool {
+	for _, entry := range entries[1:] {
+		if entry.Value != entries[0].Value {
+			return false
+		}
+	}
+	return true
+}
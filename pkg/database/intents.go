@@ -0,0 +1,92 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	IntentPut     = "put"
+	IntentDelete  = "delete"
+	IntentRename  = "rename"
+	IntentPromote = "promote"
+)
+
+// Intent is a write-ahead record of a Cloudflare mutation. It is written
+// before the remote call and removed once Cloudflare and the local database
+// both hold the result, so any intent left behind names keys that may differ
+// between the two.
+type Intent struct {
+	ID        int64    `json:"id"`
+	Op        string   `json:"op"`
+	Names     []string `json:"names"`
+	CreatedAt int64    `json:"created_at"`
+}
+
+func (cdb *Database) BeginIntent(op string, names []string) (int64, error) {
+	namesJSON, err := json.Marshal(names)
+	if err != nil {
+		return 0, fmt.Errorf("serialize intent names: %w", err)
+	}
+
+	cdb.lock.Lock()
+	defer cdb.lock.Unlock()
+
+	result, err := cdb.db.Exec(`
+		INSERT INTO intents (op, names, created_at)
+		VALUES (?, ?, ?)
+	`, op, string(namesJSON), time.Now().Unix())
+	if err != nil {
+		return 0, fmt.Errorf("record %s intent: %w", op, err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("record %s intent: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (cdb *Database) CompleteIntent(id int64) error {
+	cdb.lock.Lock()
+	defer cdb.lock.Unlock()
+
+	if _, err := cdb.db.Exec(`DELETE FROM intents WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("complete intent %d: %w", id, err)
+	}
+
+	return nil
+}
+
+// PendingIntents returns the intents that were never completed, oldest first.
+func (cdb *Database) PendingIntents() ([]Intent, error) {
+	cdb.lock.Lock()
+	defer cdb.lock.Unlock()
+
+	rows, err := cdb.db.Query(`SELECT id, op, names, created_at FROM intents ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("query intents: %w", err)
+	}
+	defer rows.Close()
+
+	intents := make([]Intent, 0)
+	for rows.Next() {
+		var intent Intent
+		var namesJSON string
+		if err := rows.Scan(&intent.ID, &intent.Op, &namesJSON, &intent.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan intent: %w", err)
+		}
+		if err := json.Unmarshal([]byte(namesJSON), &intent.Names); err != nil {
+			return nil, fmt.Errorf("parse names of intent %d: %w", intent.ID, err)
+		}
+		intents = append(intents, intent)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate intents: %w", err)
+	}
+
+	return intents, nil
+}
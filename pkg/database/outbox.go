@@ -0,0 +1,216 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"cdnmanager/pkg/models"
+)
+
+const (
+	OutboxPut    = "put"
+	OutboxDelete = "delete"
+)
+
+// OutboxItem is a mutation already applied to the local database that still
+// has to be written to Cloudflare.
+type OutboxItem struct {
+	ID            int64        `json:"id"`
+	Op            string       `json:"op"`
+	Entry         models.Entry `json:"entry"`
+	Attempts      int          `json:"attempts"`
+	LastError     string       `json:"last_error"`
+	CreatedAt     int64        `json:"created_at"`
+	NextAttemptAt int64        `json:"next_attempt_at"`
+}
+
+// EnqueuePut upserts entry locally and queues it for Cloudflare in one
+// transaction.
+func (cdb *Database) EnqueuePut(entry models.Entry) error {
+	cdb.lock.Lock()
+	defer cdb.lock.Unlock()
+
+	metadata, err := entry.Metadata.ToJSONString()
+	if err != nil {
+		return fmt.Errorf("serialize metadata for %q: %w", entry.Name, err)
+	}
+
+	tx, err := cdb.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin enqueue transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO records (name, value, metadata)
+		VALUES (?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			value = excluded.value,
+			metadata = excluded.metadata
+	`, entry.Name, entry.Value, metadata); err != nil {
+		return fmt.Errorf("upsert entry %q: %w", entry.Name, err)
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO outbox (op, name, value, metadata, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, OutboxPut, entry.Name, entry.Value, metadata, time.Now().Unix()); err != nil {
+		return fmt.Errorf("queue put %q: %w", entry.Name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit enqueue transaction: %w", err)
+	}
+
+	return nil
+}
+
+// EnqueueDelete deletes name locally and queues the delete for Cloudflare in
+// one transaction.
+func (cdb *Database) EnqueueDelete(name string) error {
+	cdb.lock.Lock()
+	defer cdb.lock.Unlock()
+
+	tx, err := cdb.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin enqueue transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM records WHERE name = ?`, name); err != nil {
+		return fmt.Errorf("delete name %q: %w", name, err)
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO outbox (op, name, created_at)
+		VALUES (?, ?, ?)
+	`, OutboxDelete, name, time.Now().Unix()); err != nil {
+		return fmt.Errorf("queue delete %q: %w", name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit enqueue transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ListOutbox returns the queued mutations oldest first.
+func (cdb *Database) ListOutbox() ([]OutboxItem, error) {
+	cdb.lock.Lock()
+	defer cdb.lock.Unlock()
+
+	rows, err := cdb.db.Query(`
+		SELECT id, op, name, value, metadata, attempts, last_error, created_at, next_attempt_at
+		FROM outbox
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query outbox: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]OutboxItem, 0)
+	for rows.Next() {
+		var item OutboxItem
+		var metadataStr string
+		if err := rows.Scan(
+			&item.ID,
+			&item.Op,
+			&item.Entry.Name,
+			&item.Entry.Value,
+			&metadataStr,
+			&item.Attempts,
+			&item.LastError,
+			&item.CreatedAt,
+			&item.NextAttemptAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan outbox item: %w", err)
+		}
+
+		if item.Op == OutboxPut {
+			metadata, err := models.MetadataFromJSONString(metadataStr)
+			if err != nil {
+				return nil, fmt.Errorf("parse metadata for %q: %w", item.Entry.Name, err)
+			}
+			item.Entry.Metadata = metadata
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate outbox: %w", err)
+	}
+
+	return items, nil
+}
+
+// OutboxNames returns the set of keys with queued mutations.
+func (cdb *Database) OutboxNames() (map[string]bool, error) {
+	cdb.lock.Lock()
+	defer cdb.lock.Unlock()
+
+	rows, err := cdb.db.Query(`SELECT DISTINCT name FROM outbox`)
+	if err != nil {
+		return nil, fmt.Errorf("query outbox names: %w", err)
+	}
+	defer rows.Close()
+
+	names := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan outbox name: %w", err)
+		}
+		names[name] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate outbox names: %w", err)
+	}
+
+	return names, nil
+}
+
+func (cdb *Database) CompleteOutboxItem(id int64) error {
+	return cdb.DiscardOutboxItem(id)
+}
+
+func (cdb *Database) FailOutboxItem(id int64, cause error, nextAttemptAt int64) error {
+	cdb.lock.Lock()
+	defer cdb.lock.Unlock()
+
+	if _, err := cdb.db.Exec(`
+		UPDATE outbox
+		SET attempts = attempts + 1, last_error = ?, next_attempt_at = ?
+		WHERE id = ?
+	`, cause.Error(), nextAttemptAt, id); err != nil {
+		return fmt.Errorf("record outbox failure %d: %w", id, err)
+	}
+
+	return nil
+}
+
+// DiscardOutboxItem drops a queued mutation without sending it. The local
+// change it describes stays until the next sync restores Cloudflare's state.
+func (cdb *Database) DiscardOutboxItem(id int64) error {
+	cdb.lock.Lock()
+	defer cdb.lock.Unlock()
+
+	result, err := cdb.db.Exec(`DELETE FROM outbox WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete outbox item %d: %w", id, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete outbox item %d: %w", id, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("outbox item %d: %w", id, sql.ErrNoRows)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package database
+
+import (
+	"fmt"
+
+	"cdnmanager/pkg/validation"
+)
+
+type CountBucket struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+type Stats struct {
+	Total    int `json:"total"`
+	External int `json:"external"`
+	Internal int `json:"internal"`
+
+	ByMimeType      []CountBucket `json:"by_mimetype"`
+	ByLocation      []CountBucket `json:"by_location"`
+	ModifiedByMonth []CountBucket `json:"modified_by_month"`
+	ValueLengths    []CountBucket `json:"value_lengths"`
+
+	TotalMetadataBytes   int `json:"total_metadata_bytes"`
+	LargestMetadataBytes int `json:"largest_metadata_bytes"`
+	MetadataLimitBytes   int `json:"metadata_limit_bytes"`
+	OverMetadataLimit    int `json:"over_metadata_limit"`
+}
+
+func (cdb *Database) Stats() (Stats, error) {
+	cdb.lock.Lock()
+	defer cdb.lock.Unlock()
+
+	stats := Stats{MetadataLimitBytes: validation.MaxMetadataBytes}
+
+	if err := cdb.db.QueryRow(`
+		SELECT
+			COUNT(*),
+			COALESCE(SUM(json_extract(metadata, '$.external') = 1), 0),
+			COALESCE(SUM(length(CAST(metadata AS BLOB))), 0),
+			COALESCE(MAX(length(CAST(metadata AS BLOB))), 0),
+			COALESCE(SUM(length(CAST(metadata AS BLOB)) > ?), 0)
+		FROM records
+	`, validation.MaxMetadataBytes).Scan(
+		&stats.Total,
+		&stats.External,
+		&stats.TotalMetadataBytes,
+		&stats.LargestMetadataBytes,
+		&stats.OverMetadataLimit,
+	); err != nil {
+		return Stats{}, fmt.Errorf("query record totals: %w", err)
+	}
+	stats.Internal = stats.Total - stats.External
+
+	var err error
+
+	stats.ByMimeType, err = cdb.countBuckets(`
+		SELECT COALESCE(NULLIF(json_extract(metadata, '$.mimetype'), ''), '(none)') AS label, COUNT(*)
+		FROM records GROUP BY label ORDER BY COUNT(*) DESC, label
+	`)
+	if err != nil {
+		return Stats{}, fmt.Errorf("count records by mimetype: %w", err)
+	}
+
+	stats.ByLocation, err = cdb.countBuckets(`
+		SELECT COALESCE(NULLIF(json_extract(metadata, '$.location'), ''), '(none)') AS label, COUNT(*)
+		FROM records GROUP BY label ORDER BY COUNT(*) DESC, label
+	`)
+	if err != nil {
+		return Stats{}, fmt.Errorf("count records by location: %w", err)
+	}
+
+	stats.ModifiedByMonth, err = cdb.countBuckets(`
+		SELECT COALESCE(strftime('%Y-%m', NULLIF(json_extract(metadata, '$.modified'), 0), 'unixepoch'), '(unknown)') AS label, COUNT(*)
+		FROM records GROUP BY label ORDER BY label
+	`)
+	if err != nil {
+		return Stats{}, fmt.Errorf("count records by month: %w", err)
+	}
+
+	stats.ValueLengths, err = cdb.countBuckets(`
+		SELECT
+			CASE
+				WHEN length(value) < 64 THEN '0-63'
+				WHEN length(value) < 128 THEN '64-127'
+				WHEN length(value) < 256 THEN '128-255'
+				WHEN length(value) < 512 THEN '256-511'
+				WHEN length(value) < 1024 THEN '512-1023'
+				ELSE '1024+'
+			END AS label,
+			COUNT(*)
+		FROM records GROUP BY label ORDER BY MIN(length(value))
+	`)
+	if err != nil {
+		return Stats{}, fmt.Errorf("count records by value length: %w", err)
+	}
+
+	return stats, nil
+}
+
+func (cdb *Database) countBuckets(query string, args ...interface{}) ([]CountBucket, error) {
+	rows, err := cdb.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := make([]CountBucket, 0)
+	for rows.Next() {
+		var bucket CountBucket
+		if err := rows.Scan(&bucket.Label, &bucket.Count); err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets, rows.Err()
+}
@@ -0,0 +1,166 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	SyncTriggerManual = "manual"
+	SyncTriggerAuto   = "auto"
+)
+
+// SyncRun records one sync from Cloudflare. Times are unix milliseconds.
+type SyncRun struct {
+	ID         int64    `json:"id"`
+	Trigger    string   `json:"trigger"`
+	StartedAt  int64    `json:"started_at"`
+	FinishedAt int64    `json:"finished_at"`
+	DurationMs int64    `json:"duration_ms"`
+	RemoteKeys int      `json:"remote_keys"`
+	Inserted   []string `json:"inserted"`
+	Updated    []string `json:"updated"`
+	Deleted    []string `json:"deleted"`
+	Error      string   `json:"error"`
+}
+
+// SyncRunSummary is a SyncRun with counts in place of the key names.
+type SyncRunSummary struct {
+	ID         int64  `json:"id"`
+	Trigger    string `json:"trigger"`
+	StartedAt  int64  `json:"started_at"`
+	FinishedAt int64  `json:"finished_at"`
+	DurationMs int64  `json:"duration_ms"`
+	RemoteKeys int    `json:"remote_keys"`
+	Inserted   int    `json:"inserted"`
+	Updated    int    `json:"updated"`
+	Deleted    int    `json:"deleted"`
+	Error      string `json:"error"`
+}
+
+func (cdb *Database) RecordSyncRun(run SyncRun) (int64, error) {
+	names := make([]string, 0, 3)
+	for _, list := range [][]string{run.Inserted, run.Updated, run.Deleted} {
+		if list == nil {
+			list = []string{}
+		}
+		encoded, err := json.Marshal(list)
+		if err != nil {
+			return 0, fmt.Errorf("serialize sync run names: %w", err)
+		}
+		names = append(names, string(encoded))
+	}
+
+	cdb.lock.Lock()
+	defer cdb.lock.Unlock()
+
+	result, err := cdb.db.Exec(`
+		INSERT INTO sync_runs (trigger, started_at, finished_at, duration_ms, remote_keys, inserted, updated, deleted, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, run.Trigger, run.StartedAt, run.FinishedAt, run.DurationMs, run.RemoteKeys, names[0], names[1], names[2], run.Error)
+	if err != nil {
+		return 0, fmt.Errorf("record sync run: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("record sync run: %w", err)
+	}
+
+	return id, nil
+}
+
+// ListSyncRuns returns the most recent sync runs first, at most limit of them
+// when limit is positive.
+func (cdb *Database) ListSyncRuns(limit int) ([]SyncRunSummary, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+
+	cdb.lock.Lock()
+	defer cdb.lock.Unlock()
+
+	rows, err := cdb.db.Query(`
+		SELECT id, trigger, started_at, finished_at, duration_ms, remote_keys,
+			json_array_length(inserted), json_array_length(updated), json_array_length(deleted), error
+		FROM sync_runs
+		ORDER BY id DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query sync runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := make([]SyncRunSummary, 0)
+	for rows.Next() {
+		var run SyncRunSummary
+		if err := rows.Scan(
+			&run.ID,
+			&run.Trigger,
+			&run.StartedAt,
+			&run.FinishedAt,
+			&run.DurationMs,
+			&run.RemoteKeys,
+			&run.Inserted,
+			&run.Updated,
+			&run.Deleted,
+			&run.Error,
+		); err != nil {
+			return nil, fmt.Errorf("scan sync run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sync runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+func (cdb *Database) GetSyncRun(id int64) (SyncRun, error) {
+	cdb.lock.Lock()
+	defer cdb.lock.Unlock()
+
+	var run SyncRun
+	var inserted, updated, deleted string
+	err := cdb.db.QueryRow(`
+		SELECT id, trigger, started_at, finished_at, duration_ms, remote_keys, inserted, updated, deleted, error
+		FROM sync_runs
+		WHERE id = ?
+	`, id).Scan(
+		&run.ID,
+		&run.Trigger,
+		&run.StartedAt,
+		&run.FinishedAt,
+		&run.DurationMs,
+		&run.RemoteKeys,
+		&inserted,
+		&updated,
+		&deleted,
+		&run.Error,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return SyncRun{}, fmt.Errorf("sync run %d not found", id)
+		}
+		return SyncRun{}, fmt.Errorf("get sync run %d: %w", id, err)
+	}
+
+	for _, field := range []struct {
+		raw  string
+		dest *[]string
+	}{
+		{inserted, &run.Inserted},
+		{updated, &run.Updated},
+		{deleted, &run.Deleted},
+	} {
+		if err := json.Unmarshal([]byte(field.raw), field.dest); err != nil {
+			return SyncRun{}, fmt.Errorf("parse names of sync run %d: %w", id, err)
+		}
+	}
+
+	return run, nil
+}
@@ -0,0 +1,113 @@
+package filescan
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const sniffLength = 512
+
+type FileInfo struct {
+	Path     string `json:"path"`
+	Name     string `json:"name"`
+	Size     int64  `json:"size"`
+	MD5      string `json:"md5"`
+	MimeType string `json:"mimetype"`
+}
+
+func ScanFile(path string) (FileInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return FileInfo{}, fmt.Errorf("open %q: %w", path, err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return FileInfo{}, fmt.Errorf("stat %q: %w", path, err)
+	}
+	if stat.IsDir() {
+		return FileInfo{}, fmt.Errorf("%q is a directory", path)
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return FileInfo{}, fmt.Errorf("read %q: %w", path, err)
+	}
+	head = head[:n]
+
+	hash := md5.New()
+	hash.Write(head)
+	if _, err := io.Copy(hash, file); err != nil {
+		return FileInfo{}, fmt.Errorf("hash %q: %w", path, err)
+	}
+
+	base := filepath.Base(path)
+
+	return FileInfo{
+		Path:     path,
+		Name:     strings.TrimSuffix(base, filepath.Ext(base)),
+		Size:     stat.Size(),
+		MD5:      hex.EncodeToString(hash.Sum(nil)),
+		MimeType: detectMimeType(base, head),
+	}, nil
+}
+
+func MD5File(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("open %q: %w", path, err)
+	}
+	defer file.Close()
+
+	hash := md5.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("hash %q: %w", path, err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ListFiles returns the regular, non-hidden files directly inside dir.
+func ListFiles(dir string) ([]string, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read directory %q: %w", dir, err)
+	}
+
+	paths := make([]string, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.Type().IsRegular() || strings.HasPrefix(dirEntry.Name(), ".") {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, dirEntry.Name()))
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// detectMimeType prefers the extension, which is more specific for office
+// documents and text formats, and falls back to sniffing the content.
+func detectMimeType(name string, head []byte) string {
+	if byExtension := mime.TypeByExtension(strings.ToLower(filepath.Ext(name))); byExtension != "" {
+		if mediaType, _, err := mime.ParseMediaType(byExtension); err == nil {
+			return mediaType
+		}
+	}
+
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return mediaType
+}
@@ -0,0 +1,113 @@
+package linkcheck
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"cdnmanager/pkg/models"
+	"cdnmanager/pkg/validation"
+)
+
+const (
+	DefaultConcurrency = 8
+	DefaultTimeout     = 15 * time.Second
+)
+
+type Checker struct {
+	Client      *http.Client
+	Concurrency int
+	Timeout     time.Duration
+}
+
+func NewChecker() *Checker {
+	return &Checker{
+		Client:      &http.Client{},
+		Concurrency: DefaultConcurrency,
+		Timeout:     DefaultTimeout,
+	}
+}
+
+// Check requests the Value of every entry, running at most Concurrency
+// requests at a time. Results are returned in the order of entries.
+func (c *Checker) Check(ctx context.Context, entries []models.Entry) []models.LinkCheck {
+	concurrency := c.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]models.LinkCheck, len(entries))
+	queue := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				results[i] = c.CheckEntry(ctx, entries[i])
+			}
+		}()
+	}
+
+	for i := range entries {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	return results
+}
+
+func (c *Checker) CheckEntry(ctx context.Context, entry models.Entry) models.LinkCheck {
+	result := models.LinkCheck{
+		Name:      entry.Name,
+		URL:       entry.Value,
+		CheckedAt: time.Now().Unix(),
+	}
+
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	resp, err := c.request(ctx, http.MethodHead, entry.Value)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		// some servers reject HEAD outright; retry with GET
+		resp.Body.Close()
+		resp, err = c.request(ctx, http.MethodGet, entry.Value)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	result.FinalURL = resp.Request.URL.String()
+	result.ContentType = resp.Header.Get("Content-Type")
+
+	if result.ContentType != "" && entry.Metadata.MimeType != "" && resp.StatusCode < 400 {
+		result.MimeTypeMismatch = validation.NormalizeMimeType(result.ContentType) !=
+			validation.NormalizeMimeType(entry.Metadata.MimeType)
+	}
+
+	return result
+}
+
+func (c *Checker) request(ctx context.Context, method string, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build %s request: %w", method, err)
+	}
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return client.Do(req)
+}
@@ -0,0 +1,134 @@
+package linkcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cdnmanager/pkg/models"
+)
+
+func TestCheckEntry(t *testing.T) {
+	release := make(chan struct{})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/get-only", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	defer close(release)
+
+	checker := NewChecker()
+	checker.Timeout = 200 * time.Millisecond
+
+	tests := []struct {
+		name        string
+		path        string
+		mimeType    string
+		status      int
+		finalPath   string
+		contentType string
+		mismatch    bool
+		wantErr     bool
+	}{
+		{name: "ok", path: "/ok", mimeType: "image/png", status: http.StatusOK, finalPath: "/ok", contentType: "image/png"},
+		{name: "mimetype mismatch", path: "/ok", mimeType: "image/jpeg", status: http.StatusOK, finalPath: "/ok", contentType: "image/png", mismatch: true},
+		{name: "not found", path: "/missing", mimeType: "image/png", status: http.StatusNotFound, finalPath: "/missing", contentType: "text/plain; charset=utf-8"},
+		{name: "redirect", path: "/moved", mimeType: "image/png", status: http.StatusOK, finalPath: "/ok", contentType: "image/png"},
+		{name: "head rejected", path: "/get-only", mimeType: "text/plain", status: http.StatusOK, finalPath: "/get-only", contentType: "text/plain"},
+		{name: "timeout", path: "/slow", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := models.Entry{
+				Name:     tt.name,
+				Value:    server.URL + tt.path,
+				Metadata: models.Metadata{MimeType: tt.mimeType},
+			}
+
+			result := checker.CheckEntry(context.Background(), entry)
+
+			if tt.wantErr {
+				if result.Error == "" {
+					t.Fatalf("expected an error, got status %d", result.StatusCode)
+				}
+				if result.StatusCode != 0 {
+					t.Errorf("status code = %d, want 0", result.StatusCode)
+				}
+				return
+			}
+
+			if result.Error != "" {
+				t.Fatalf("unexpected error: %s", result.Error)
+			}
+			if result.StatusCode != tt.status {
+				t.Errorf("status code = %d, want %d", result.StatusCode, tt.status)
+			}
+			if result.FinalURL != server.URL+tt.finalPath {
+				t.Errorf("final url = %q, want %q", result.FinalURL, server.URL+tt.finalPath)
+			}
+			if result.ContentType != tt.contentType {
+				t.Errorf("content type = %q, want %q", result.ContentType, tt.contentType)
+			}
+			if result.MimeTypeMismatch != tt.mismatch {
+				t.Errorf("mimetype mismatch = %v, want %v", result.MimeTypeMismatch, tt.mismatch)
+			}
+		})
+	}
+}
+
+func TestCheckKeepsOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	entries := []models.Entry{
+		{Name: "a", Value: server.URL + "/ok"},
+		{Name: "b", Value: server.URL + "/missing"},
+		{Name: "c", Value: server.URL + "/ok"},
+	}
+
+	checker := NewChecker()
+	checker.Concurrency = 2
+
+	results := checker.Check(context.Background(), entries)
+	if len(results) != len(entries) {
+		t.Fatalf("got %d results, want %d", len(results), len(entries))
+	}
+
+	for i, entry := range entries {
+		if results[i].Name != entry.Name {
+			t.Errorf("result %d is for %q, want %q", i, results[i].Name, entry.Name)
+		}
+	}
+	if results[1].StatusCode != http.StatusNotFound {
+		t.Errorf("status code for b = %d, want %d", results[1].StatusCode, http.StatusNotFound)
+	}
+}
@@ -1,8 +1,11 @@
 package models
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 )
 
 type Entry struct {
@@ -21,6 +24,107 @@ type Metadata struct {
 	Description    string `json:"description,omitempty"`
 
 	Modified int64 `json:"modified,omitempty"`
+
+	// Extra holds fields not modelled above so they survive a read/write
+	// cycle. It is flattened into the top level of the JSON object.
+	Extra map[string]any `json:"-"`
+}
+
+// MetadataFields lists the JSON names of the built-in metadata fields.
+var MetadataFields = []string{
+	"name",
+	"external",
+	"mimetype",
+	"location",
+	"cloud_storage_id",
+	"md5Checksum",
+	"description",
+	"modified",
+}
+
+func IsMetadataField(field string) bool {
+	for _, known := range MetadataFields {
+		if field == known {
+			return true
+		}
+	}
+	return false
+}
+
+type metadataFields Metadata
+
+func (m Metadata) MarshalJSON() ([]byte, error) {
+	base, err := json.Marshal(metadataFields(m))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(m.Extra) == 0 {
+		return base, nil
+	}
+
+	keys := make([]string, 0, len(m.Extra))
+	for key := range m.Extra {
+		if !IsMetadataField(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.Write(base[:len(base)-1])
+
+	for _, key := range keys {
+		keyJSON, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		valueJSON, err := json.Marshal(m.Extra[key])
+		if err != nil {
+			return nil, fmt.Errorf("marshal extra field %q: %w", key, err)
+		}
+
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.Write(keyJSON)
+		buf.WriteByte(':')
+		buf.Write(valueJSON)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (m *Metadata) UnmarshalJSON(data []byte) error {
+	var fields metadataFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	for key, value := range raw {
+		if IsMetadataField(key) {
+			continue
+		}
+
+		var decoded any
+		if err := json.Unmarshal(value, &decoded); err != nil {
+			return fmt.Errorf("unmarshal extra field %q: %w", key, err)
+		}
+
+		if fields.Extra == nil {
+			fields.Extra = make(map[string]any)
+		}
+		fields.Extra[key] = decoded
+	}
+
+	*m = Metadata(fields)
+	return nil
 }
 
 func (m Metadata) ToJSONString() (string, error) {
@@ -54,3 +158,91 @@ func EntryFromJSONString(jsonStr string) (Entry, error) {
 	}
 	return entry, nil
 }
+
+type MetadataPatch struct {
+	Name           *string `json:"name,omitempty"`
+	External       *bool   `json:"external,omitempty"`
+	MimeType       *string `json:"mimetype,omitempty"`
+	Location       *string `json:"location,omitempty"`
+	CloudStorageID *string `json:"cloud_storage_id,omitempty"`
+	MD5Checksum    *string `json:"md5Checksum,omitempty"`
+	Description    *string `json:"description,omitempty"`
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+func (p MetadataPatch) IsEmpty() bool {
+	return p.Name == nil &&
+		p.External == nil &&
+		p.MimeType == nil &&
+		p.Location == nil &&
+		p.CloudStorageID == nil &&
+		p.MD5Checksum == nil &&
+		p.Description == nil
+}
+
+func (p MetadataPatch) Apply(m Metadata) Metadata {
+	if p.Name != nil {
+		m.Name = *p.Name
+	}
+	if p.External != nil {
+		m.External = *p.External
+	}
+	if p.MimeType != nil {
+		m.MimeType = *p.MimeType
+	}
+	if p.Location != nil {
+		m.Location = *p.Location
+	}
+	if p.CloudStorageID != nil {
+		m.CloudStorageID = *p.CloudStorageID
+	}
+	if p.MD5Checksum != nil {
+		m.MD5Checksum = *p.MD5Checksum
+	}
+	if p.Description != nil {
+		m.Description = *p.Description
+	}
+	return m
+}
+
+// DiffMetadata lists the user-editable fields that differ between old and new.
+// Modified is excluded because every write restamps it.
+func DiffMetadata(old, new Metadata) []FieldChange {
+	changes := make([]FieldChange, 0)
+
+	add := func(field, oldValue, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, FieldChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+
+	add("name", old.Name, new.Name)
+	add("external", strconv.FormatBool(old.External), strconv.FormatBool(new.External))
+	add("mimetype", old.MimeType, new.MimeType)
+	add("location", old.Location, new.Location)
+	add("cloud_storage_id", old.CloudStorageID, new.CloudStorageID)
+	add("md5Checksum", old.MD5Checksum, new.MD5Checksum)
+	add("description", old.Description, new.Description)
+
+	return changes
+}
+
+type LinkCheck struct {
+	Name             string `json:"name"`
+	URL              string `json:"url"`
+	StatusCode       int    `json:"status_code"`
+	FinalURL         string `json:"final_url"`
+	ContentType      string `json:"content_type"`
+	MimeTypeMismatch bool   `json:"mimetype_mismatch"`
+	Error            string `json:"error"`
+	CheckedAt        int64  `json:"checked_at"`
+}
+
+func (c LinkCheck) IsBroken() bool {
+	return c.Error != "" || c.StatusCode >= 400
+}
@@ -0,0 +1,158 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMetadataRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Metadata
+		// output is the re-serialized form, with Extra keys sorted after the
+		// built-in fields
+		output string
+	}{
+		{
+			name:   "built-in fields only",
+			input:  `{"name":"a.png","external":false,"mimetype":"image/png","location":"bucket","modified":5}`,
+			want:   Metadata{Name: "a.png", MimeType: "image/png", Location: "bucket", Modified: 5},
+			output: `{"name":"a.png","external":false,"mimetype":"image/png","location":"bucket","modified":5}`,
+		},
+		{
+			name:  "unknown keys are kept",
+			input: `{"name":"a.png","external":true,"mimetype":"image/png","location":"","zeta":"z","alpha":"a"}`,
+			want: Metadata{
+				Name:     "a.png",
+				External: true,
+				MimeType: "image/png",
+				Extra:    map[string]any{"zeta": "z", "alpha": "a"},
+			},
+			output: `{"name":"a.png","external":true,"mimetype":"image/png","location":"","alpha":"a","zeta":"z"}`,
+		},
+		{
+			name:  "numbers, booleans, null and nesting",
+			input: `{"name":"a","external":true,"mimetype":"text/plain","location":"","size":1024,"ratio":0.5,"flag":false,"none":null,"tags":["x","y"],"owner":{"team":"web","ids":[1,2]}}`,
+			want: Metadata{
+				Name:     "a",
+				External: true,
+				MimeType: "text/plain",
+				Extra: map[string]any{
+					"size":  1024.0,
+					"ratio": 0.5,
+					"flag":  false,
+					"none":  nil,
+					"tags":  []any{"x", "y"},
+					"owner": map[string]any{"team": "web", "ids": []any{1.0, 2.0}},
+				},
+			},
+			output: `{"name":"a","external":true,"mimetype":"text/plain","location":"","flag":false,"none":null,"owner":{"ids":[1,2],"team":"web"},"ratio":0.5,"size":1024,"tags":["x","y"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, err := MetadataFromJSONString(tt.input)
+			if err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(meta, tt.want) {
+				t.Fatalf("unmarshal = %#v, want %#v", meta, tt.want)
+			}
+
+			output, err := meta.ToJSONString()
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			if output != tt.output {
+				t.Errorf("marshal = %s, want %s", output, tt.output)
+			}
+
+			again, err := MetadataFromJSONString(output)
+			if err != nil {
+				t.Fatalf("unmarshal again: %v", err)
+			}
+			if !reflect.DeepEqual(again, meta) {
+				t.Errorf("second round trip = %#v, want %#v", again, meta)
+			}
+		})
+	}
+}
+
+func TestMetadataExtraCannotShadowBuiltInFields(t *testing.T) {
+	meta := Metadata{
+		Name:     "real",
+		MimeType: "image/png",
+		Extra: map[string]any{
+			"name":     "shadow",
+			"modified": 99,
+			"custom":   "kept",
+		},
+	}
+
+	output, err := meta.ToJSONString()
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	want := `{"name":"real","external":false,"mimetype":"image/png","location":"","custom":"kept"}`
+	if output != want {
+		t.Errorf("marshal = %s, want %s", output, want)
+	}
+
+	parsed, err := MetadataFromJSONString(output)
+	if err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if parsed.Name != "real" || parsed.Modified != 0 {
+		t.Errorf("built-in fields = %q/%d, want %q/0", parsed.Name, parsed.Modified, "real")
+	}
+	if !reflect.DeepEqual(parsed.Extra, map[string]any{"custom": "kept"}) {
+		t.Errorf("extra = %#v, want only custom", parsed.Extra)
+	}
+}
+
+func TestMetadataMarshalIsDeterministic(t *testing.T) {
+	meta := Metadata{
+		Name:     "a",
+		MimeType: "image/png",
+		Extra:    map[string]any{},
+	}
+	for _, key := range []string{"k", "b", "x", "a", "m", "z", "c", "q"} {
+		meta.Extra[key] = key
+	}
+
+	first, err := meta.ToJSONString()
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	for i := 0; i < 50; i++ {
+		output, err := meta.ToJSONString()
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		if output != first {
+			t.Fatalf("marshal %d = %s, want %s", i, output, first)
+		}
+	}
+}
+
+func TestMetadataEmptyObject(t *testing.T) {
+	meta, err := MetadataFromJSONString(`{}`)
+	if err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if meta.Extra != nil {
+		t.Errorf("extra = %#v, want nil", meta.Extra)
+	}
+
+	output, err := meta.ToJSONString()
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if want := `{"name":"","external":false,"mimetype":"","location":""}`; output != want {
+		t.Errorf("marshal = %s, want %s", output, want)
+	}
+}
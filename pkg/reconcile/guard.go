@@ -0,0 +1,83 @@
+package reconcile
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrMassDeletion is wrapped by every MassDeletionError.
+var ErrMassDeletion = errors.New("mass deletion guard")
+
+// MassDeletionError reports a plan whose deletes exceed the absolute or the
+// percentage limit, or that deletes every local record. The sync can be
+// applied anyway once confirmed.
+type MassDeletionError struct {
+	Deletes          int     `json:"deletes"`
+	LocalRecords     int     `json:"local_records"`
+	Percent          float64 `json:"percent"`
+	MaxDeletes       int     `json:"max_deletes"`
+	MaxDeletePercent int     `json:"max_delete_percent"`
+
+	// Names lists the keys the plan would delete; confirming the sync allows
+	// only these.
+	Names []string `json:"names"`
+}
+
+func (e *MassDeletionError) Error() string {
+	return fmt.Sprintf(
+		"%v: sync would delete %d of %d local records (%.1f%%), above the limit of %d records or %d%%; confirm to apply",
+		ErrMassDeletion,
+		e.Deletes,
+		e.LocalRecords,
+		e.Percent,
+		e.MaxDeletes,
+		e.MaxDeletePercent,
+	)
+}
+
+func (e *MassDeletionError) Unwrap() error {
+	return ErrMassDeletion
+}
+
+// Allows reports whether plan deletes only keys listed in e, so confirming e
+// also confirms plan. A nil e allows nothing.
+func (e *MassDeletionError) Allows(plan Plan) bool {
+	if e == nil {
+		return false
+	}
+
+	confirmed := make(map[string]bool, len(e.Names))
+	for _, name := range e.Names {
+		confirmed[name] = true
+	}
+	for _, name := range plan.ToDelete {
+		if !confirmed[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckDeletes returns a *MassDeletionError when plan deletes more than
+// maxDeletes of localRecords, more than maxDeletePercent percent of them, or
+// all of them.
+func CheckDeletes(plan Plan, localRecords int, maxDeletes int, maxDeletePercent int) error {
+	deletes := len(plan.ToDelete)
+	if deletes == 0 || localRecords == 0 {
+		return nil
+	}
+
+	percent := float64(deletes) * 100 / float64(localRecords)
+	if deletes < localRecords && deletes <= maxDeletes && percent <= float64(maxDeletePercent) {
+		return nil
+	}
+
+	return &MassDeletionError{
+		Deletes:          deletes,
+		LocalRecords:     localRecords,
+		Percent:          percent,
+		MaxDeletes:       maxDeletes,
+		MaxDeletePercent: maxDeletePercent,
+		Names:            append([]string(nil), plan.ToDelete...),
+	}
+}
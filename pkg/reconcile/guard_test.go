@@ -0,0 +1,108 @@
+package reconcile
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func planDeleting(count int) Plan {
+	plan := Plan{ToDelete: make([]string, 0, count)}
+	for i := 0; i < count; i++ {
+		plan.ToDelete = append(plan.ToDelete, fmt.Sprintf("key-%d", i))
+	}
+	return plan
+}
+
+func TestCheckDeletes(t *testing.T) {
+	tests := []struct {
+		name             string
+		deletes          int
+		localRecords     int
+		maxDeletes       int
+		maxDeletePercent int
+		blocked          bool
+	}{
+		{name: "no deletes", deletes: 0, localRecords: 10, maxDeletes: 0, maxDeletePercent: 0},
+		{name: "no local records", deletes: 5, localRecords: 0, maxDeletes: 1, maxDeletePercent: 1},
+		{name: "below both limits", deletes: 4, localRecords: 100, maxDeletes: 5, maxDeletePercent: 10},
+		{name: "exactly max deletes", deletes: 5, localRecords: 100, maxDeletes: 5, maxDeletePercent: 10},
+		{name: "one over max deletes", deletes: 6, localRecords: 100, maxDeletes: 5, maxDeletePercent: 10, blocked: true},
+		{name: "exactly max deletes but over percent", deletes: 5, localRecords: 20, maxDeletes: 5, maxDeletePercent: 10, blocked: true},
+		{name: "exactly percent limit", deletes: 10, localRecords: 100, maxDeletes: 50, maxDeletePercent: 10},
+		{name: "just over percent limit", deletes: 11, localRecords: 100, maxDeletes: 50, maxDeletePercent: 10, blocked: true},
+		{name: "all records within limits", deletes: 3, localRecords: 3, maxDeletes: 50, maxDeletePercent: 100, blocked: true},
+		{name: "single record", deletes: 1, localRecords: 1, maxDeletes: 50, maxDeletePercent: 100, blocked: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := planDeleting(tt.deletes)
+
+			err := CheckDeletes(plan, tt.localRecords, tt.maxDeletes, tt.maxDeletePercent)
+			if !tt.blocked {
+				if err != nil {
+					t.Fatalf("CheckDeletes = %v, want nil", err)
+				}
+				return
+			}
+
+			var massDeletion *MassDeletionError
+			if !errors.As(err, &massDeletion) {
+				t.Fatalf("CheckDeletes = %v, want a *MassDeletionError", err)
+			}
+			if !errors.Is(err, ErrMassDeletion) {
+				t.Errorf("error does not wrap ErrMassDeletion")
+			}
+			if massDeletion.Deletes != tt.deletes || massDeletion.LocalRecords != tt.localRecords {
+				t.Errorf("deletes = %d of %d, want %d of %d", massDeletion.Deletes, massDeletion.LocalRecords, tt.deletes, tt.localRecords)
+			}
+			if !reflect.DeepEqual(massDeletion.Names, plan.ToDelete) {
+				t.Errorf("names = %v, want %v", massDeletion.Names, plan.ToDelete)
+			}
+		})
+	}
+}
+
+func TestCheckDeletesCopiesNames(t *testing.T) {
+	plan := planDeleting(3)
+
+	err := CheckDeletes(plan, 3, 50, 100)
+	var massDeletion *MassDeletionError
+	if !errors.As(err, &massDeletion) {
+		t.Fatalf("CheckDeletes = %v, want a *MassDeletionError", err)
+	}
+
+	plan.ToDelete[0] = "changed"
+	if massDeletion.Names[0] != "key-0" {
+		t.Errorf("names share the plan's slice: %v", massDeletion.Names)
+	}
+}
+
+func TestMassDeletionErrorAllows(t *testing.T) {
+	blocked := &MassDeletionError{Names: []string{"a", "b", "c"}}
+
+	tests := []struct {
+		name      string
+		confirmed *MassDeletionError
+		toDelete  []string
+		want      bool
+	}{
+		{name: "same deletes", confirmed: blocked, toDelete: []string{"a", "b", "c"}, want: true},
+		{name: "subset of deletes", confirmed: blocked, toDelete: []string{"b"}, want: true},
+		{name: "no deletes", confirmed: blocked, toDelete: nil, want: true},
+		{name: "one unconfirmed delete", confirmed: blocked, toDelete: []string{"a", "d"}, want: false},
+		{name: "only unconfirmed deletes", confirmed: blocked, toDelete: []string{"d", "e"}, want: false},
+		{name: "nothing confirmed", confirmed: nil, toDelete: []string{"a"}, want: false},
+		{name: "nothing confirmed and no deletes", confirmed: nil, toDelete: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.confirmed.Allows(Plan{ToDelete: tt.toDelete}); got != tt.want {
+				t.Errorf("Allows(%v) = %v, want %v", tt.toDelete, got, tt.want)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strings"
 
 	"cdnmanager/pkg/models"
 )
@@ -16,6 +17,47 @@ type Plan struct {
 	ToDelete []string
 }
 
+// Without drops every change that touches one of names.
+func (p Plan) Without(names map[string]bool) Plan {
+	if len(names) == 0 {
+		return p
+	}
+
+	filtered := Plan{}
+	for _, entry := range p.ToInsert {
+		if !names[entry.Name] {
+			filtered.ToInsert = append(filtered.ToInsert, entry)
+		}
+	}
+	for _, entry := range p.ToUpdate {
+		if !names[entry.Name] {
+			filtered.ToUpdate = append(filtered.ToUpdate, entry)
+		}
+	}
+	for _, name := range p.ToDelete {
+		if !names[name] {
+			filtered.ToDelete = append(filtered.ToDelete, name)
+		}
+	}
+	return filtered
+}
+
+// WithPrefix returns the entries whose names start with prefix, so a sync
+// scoped to a prefix never plans changes for keys outside it.
+func WithPrefix(entries []models.Entry, prefix string) []models.Entry {
+	if prefix == "" {
+		return entries
+	}
+
+	filtered := make([]models.Entry, 0, len(entries))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name, prefix) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
 func Reconcile(cloudflareEntries, databaseEntries []models.Entry) (Plan, error) {
 	cloudflareMap := make(map[string]models.Entry, len(cloudflareEntries))
 	databaseMap := make(map[string]models.Entry, len(databaseEntries))
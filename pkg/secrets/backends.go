@@ -0,0 +1,53 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+
+	"github.com/zalando/go-keyring"
+)
+
+// KeyringBackend uses the OS credential store: Keychain on macOS, Credential
+// Manager on Windows and the Secret Service on Linux.
+type KeyringBackend struct {
+	Service string
+}
+
+func (b KeyringBackend) Get(key string) (string, error) {
+	secret, err := keyring.Get(b.Service, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrNotFound
+	}
+	return secret, err
+}
+
+func (b KeyringBackend) Set(key string, secret string) error {
+	return keyring.Set(b.Service, key, secret)
+}
+
+func (b KeyringBackend) Delete(key string) error {
+	err := keyring.Delete(b.Service, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// EnvBackend reads secrets from environment variables named by the key.
+type EnvBackend struct{}
+
+func (EnvBackend) Get(key string) (string, error) {
+	secret, ok := os.LookupEnv(key)
+	if !ok || secret == "" {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+func (EnvBackend) Set(string, string) error {
+	return ErrReadOnly
+}
+
+func (EnvBackend) Delete(string) error {
+	return ErrReadOnly
+}
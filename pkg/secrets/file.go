@@ -0,0 +1,179 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	saltLength = 16
+	keyLength  = 32
+)
+
+// FileBackend keeps secrets in a JSON file, each encrypted with AES-GCM under
+// a key derived from Passphrase with scrypt.
+type FileBackend struct {
+	Path       string
+	Passphrase string
+
+	lock sync.Mutex
+}
+
+type encryptedFile struct {
+	Salt    string            `json:"salt"`
+	Secrets map[string]string `json:"secrets"`
+}
+
+func NewFileBackend(path string, passphrase string) *FileBackend {
+	return &FileBackend{Path: path, Passphrase: passphrase}
+}
+
+func (b *FileBackend) Get(key string) (string, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	file, err := b.read()
+	if err != nil {
+		return "", err
+	}
+
+	sealed, ok := file.Secrets[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	aead, err := b.cipher(file.Salt)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", fmt.Errorf("secret %q is corrupt", key)
+	}
+
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(key))
+	if err != nil {
+		return "", fmt.Errorf("decrypt secret %q: wrong passphrase or corrupt file", key)
+	}
+
+	return string(plaintext), nil
+}
+
+func (b *FileBackend) Set(key string, secret string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	file, err := b.read()
+	if err != nil {
+		return err
+	}
+
+	aead, err := b.cipher(file.Salt)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("generate nonce: %w", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(secret), []byte(key))
+	file.Secrets[key] = base64.StdEncoding.EncodeToString(sealed)
+
+	return b.write(file)
+}
+
+func (b *FileBackend) Delete(key string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	file, err := b.read()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := file.Secrets[key]; !ok {
+		return ErrNotFound
+	}
+	delete(file.Secrets, key)
+
+	return b.write(file)
+}
+
+func (b *FileBackend) read() (*encryptedFile, error) {
+	if b.Passphrase == "" {
+		return nil, fmt.Errorf("encrypted secret file requires a passphrase")
+	}
+
+	data, err := os.ReadFile(b.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		salt := make([]byte, saltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, fmt.Errorf("generate salt: %w", err)
+		}
+		return &encryptedFile{
+			Salt:    base64.StdEncoding.EncodeToString(salt),
+			Secrets: map[string]string{},
+		}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("read secret file %q: %w", b.Path, err)
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("decode secret file %q: %w", b.Path, err)
+	}
+	if file.Secrets == nil {
+		file.Secrets = map[string]string{}
+	}
+
+	return &file, nil
+}
+
+func (b *FileBackend) write(file *encryptedFile) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal secret file: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(b.Path), 0o755); err != nil {
+		return fmt.Errorf("create secret file directory: %w", err)
+	}
+
+	if err := os.WriteFile(b.Path, data, 0o600); err != nil {
+		return fmt.Errorf("write secret file %q: %w", b.Path, err)
+	}
+
+	return nil
+}
+
+func (b *FileBackend) cipher(encodedSalt string) (cipher.AEAD, error) {
+	salt, err := base64.StdEncoding.DecodeString(encodedSalt)
+	if err != nil {
+		return nil, fmt.Errorf("decode secret file salt: %w", err)
+	}
+
+	key, err := scrypt.Key([]byte(b.Passphrase), salt, 1<<15, 8, 1, keyLength)
+	if err != nil {
+		return nil, fmt.Errorf("derive key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,123 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const (
+	SchemeKeyring = "keyring"
+	SchemeEnv     = "env"
+	SchemeFile    = "file"
+)
+
+var ErrNotFound = errors.New("secret not found")
+var ErrReadOnly = errors.New("secret source is read-only")
+
+// Backend stores secrets under a key that is unique within the backend.
+type Backend interface {
+	Get(key string) (string, error)
+	Set(key string, secret string) error
+	Delete(key string) error
+}
+
+// Store resolves references of the form "scheme:key" against the backend
+// registered for the scheme.
+type Store struct {
+	lock     sync.Mutex
+	backends map[string]Backend
+}
+
+// Default holds the OS keyring and environment backends. An encrypted file
+// backend is registered at startup once its location and passphrase are known.
+var Default = NewStore()
+
+func NewStore() *Store {
+	store := &Store{backends: make(map[string]Backend)}
+	store.Register(SchemeKeyring, KeyringBackend{Service: "cdnmanager"})
+	store.Register(SchemeEnv, EnvBackend{})
+	return store
+}
+
+func (s *Store) Register(scheme string, backend Backend) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.backends[scheme] = backend
+}
+
+func (s *Store) HasBackend(scheme string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	_, ok := s.backends[scheme]
+	return ok
+}
+
+func (s *Store) Get(ref string) (string, error) {
+	backend, key, err := s.lookup(ref)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := backend.Get(key)
+	if err != nil {
+		return "", fmt.Errorf("get secret %q: %w", ref, err)
+	}
+	return secret, nil
+}
+
+func (s *Store) Set(ref string, secret string) error {
+	backend, key, err := s.lookup(ref)
+	if err != nil {
+		return err
+	}
+
+	if err := backend.Set(key, secret); err != nil {
+		return fmt.Errorf("set secret %q: %w", ref, err)
+	}
+	return nil
+}
+
+func (s *Store) Delete(ref string) error {
+	backend, key, err := s.lookup(ref)
+	if err != nil {
+		return err
+	}
+
+	if err := backend.Delete(key); err != nil {
+		return fmt.Errorf("delete secret %q: %w", ref, err)
+	}
+	return nil
+}
+
+func (s *Store) lookup(ref string) (Backend, string, error) {
+	scheme, key, err := ParseRef(ref)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.lock.Lock()
+	backend, ok := s.backends[scheme]
+	s.lock.Unlock()
+
+	if !ok {
+		return nil, "", fmt.Errorf("no secret backend configured for %q references", scheme)
+	}
+
+	return backend, key, nil
+}
+
+func Ref(scheme string, key string) string {
+	return scheme + ":" + key
+}
+
+func ParseRef(ref string) (scheme string, key string, err error) {
+	scheme, key, ok := strings.Cut(ref, ":")
+	if !ok || scheme == "" || key == "" {
+		return "", "", fmt.Errorf("secret reference %q must have the form scheme:key", ref)
+	}
+	return scheme, key, nil
+}
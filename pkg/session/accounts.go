@@ -0,0 +1,118 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	cloudflare "github.com/cloudflare/cloudflare-go/v6"
+	"github.com/cloudflare/cloudflare-go/v6/accounts"
+	"github.com/cloudflare/cloudflare-go/v6/kv"
+	"github.com/cloudflare/cloudflare-go/v6/option"
+)
+
+type Account struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type Namespace struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+func newTokenClient(token string) (*cloudflare.Client, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, fmt.Errorf("api token is required")
+	}
+
+	return cloudflare.NewClient(option.WithAPIToken(token)), nil
+}
+
+// ListAccounts returns the accounts the token can access, sorted by name.
+func ListAccounts(token string) ([]Account, error) {
+	client, err := newTokenClient(token)
+	if err != nil {
+		return nil, err
+	}
+
+	pager := client.Accounts.ListAutoPaging(context.Background(), accounts.AccountListParams{})
+
+	var result []Account
+	for pager.Next() {
+		account := pager.Current()
+		result = append(result, Account{ID: account.ID, Name: account.Name})
+	}
+
+	if err := pager.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list accounts: %w", err)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return strings.ToLower(result[i].Name) < strings.ToLower(result[j].Name)
+	})
+
+	return result, nil
+}
+
+// ListNamespaces returns the KV namespaces in an account, sorted by title.
+func ListNamespaces(token string, accountID string) ([]Namespace, error) {
+	client, err := newTokenClient(token)
+	if err != nil {
+		return nil, err
+	}
+
+	accountID = strings.TrimSpace(accountID)
+	if accountID == "" {
+		return nil, fmt.Errorf("account id is required")
+	}
+
+	pager := client.KV.Namespaces.ListAutoPaging(context.Background(), kv.NamespaceListParams{
+		AccountID: cloudflare.F(accountID),
+	})
+
+	var result []Namespace
+	for pager.Next() {
+		namespace := pager.Current()
+		result = append(result, Namespace{ID: namespace.ID, Title: namespace.Title})
+	}
+
+	if err := pager.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list KV namespaces: %w", err)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return strings.ToLower(result[i].Title) < strings.ToLower(result[j].Title)
+	})
+
+	return result, nil
+}
+
+func CreateNamespace(token string, accountID string, title string) (Namespace, error) {
+	client, err := newTokenClient(token)
+	if err != nil {
+		return Namespace{}, err
+	}
+
+	accountID = strings.TrimSpace(accountID)
+	if accountID == "" {
+		return Namespace{}, fmt.Errorf("account id is required")
+	}
+
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return Namespace{}, fmt.Errorf("namespace title is required")
+	}
+
+	namespace, err := client.KV.Namespaces.New(context.Background(), kv.NamespaceNewParams{
+		AccountID: cloudflare.F(accountID),
+		Title:     cloudflare.F(title),
+	})
+	if err != nil {
+		return Namespace{}, fmt.Errorf("failed to create KV namespace %q: %w", title, err)
+	}
+
+	return Namespace{ID: namespace.ID, Title: namespace.Title}, nil
+}
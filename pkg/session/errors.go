@@ -0,0 +1,40 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	cloudflare "github.com/cloudflare/cloudflare-go/v6"
+)
+
+// IsTransient reports whether err is worth retrying later: the request never
+// got an API response, or Cloudflare answered with a rate limit or server
+// error.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var apiErr *cloudflare.Error
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+
+	return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError
+}
+
+func isStatus(err error, codes ...int) bool {
+	var apiErr *cloudflare.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	for _, code := range codes {
+		if apiErr.StatusCode == code {
+			return true
+		}
+	}
+
+	return false
+}
@@ -15,15 +15,24 @@ import (
 
 const bulkGetChunkSize = 100
 
+// MaxBulkWriteKeys is the number of key/value pairs Cloudflare accepts in a
+// single bulk write request.
+const MaxBulkWriteKeys = 10000
+
+// MaxBulkDeleteKeys is the number of keys Cloudflare accepts in a single bulk
+// delete request.
+const MaxBulkDeleteKeys = 10000
+
 type CloudflareSession struct {
 	client      *cloudflare.Client
 	accountID   string
 	namespaceID string
 	domain      string
+	prefix      string
 }
 
 type bulkGetRawEnvelope struct {
-	Values map[string]bulkGetRawItem `json:"values"`
+	Values map[string]*bulkGetRawItem `json:"values"`
 }
 
 type bulkGetRawItem struct {
@@ -45,16 +54,27 @@ func NewCloudflareSession(cfg config.Config) (*CloudflareSession, error) {
 		accountID:   cfg.AccountID,
 		namespaceID: cfg.NamespaceID,
 		domain:      cfg.Domain,
+		prefix:      cfg.SyncPrefix,
 	}, nil
 }
 
+// Prefix returns the sync prefix the session lists keys under.
+func (s *CloudflareSession) Prefix() string {
+	return s.prefix
+}
+
 func (s *CloudflareSession) GetAllKeys() ([]kv.Key, error) {
+	params := kv.NamespaceKeyListParams{
+		AccountID: cloudflare.F(s.accountID),
+	}
+	if s.prefix != "" {
+		params.Prefix = cloudflare.F(s.prefix)
+	}
+
 	pager := s.client.KV.Namespaces.Keys.ListAutoPaging(
 		context.Background(),
 		s.namespaceID,
-		kv.NamespaceKeyListParams{
-			AccountID: cloudflare.F(s.accountID),
-		},
+		params,
 	)
 
 	var keys []kv.Key
@@ -84,6 +104,10 @@ func (s *CloudflareSession) GetAllEntriesBulk() ([]models.Entry, error) {
 		keyNames = append(keyNames, k.Name)
 	}
 
+	return s.GetEntries(keyNames)
+}
+
+func (s *CloudflareSession) GetEntries(keyNames []string) ([]models.Entry, error) {
 	entries := make([]models.Entry, 0, len(keyNames))
 
 	for start := 0; start < len(keyNames); start += bulkGetChunkSize {
@@ -118,6 +142,21 @@ func (s *CloudflareSession) GetAllEntriesBulk() ([]models.Entry, error) {
 	return entries, nil
 }
 
+func (s *CloudflareSession) GetEntry(key string) (models.Entry, bool, error) {
+	entries, err := s.GetEntries([]string{key})
+	if err != nil {
+		return models.Entry{}, false, err
+	}
+
+	for _, entry := range entries {
+		if entry.Name == key {
+			return entry, true, nil
+		}
+	}
+
+	return models.Entry{}, false, nil
+}
+
 func (s *CloudflareSession) Size() (int, []kv.Key) {
 	entries, err := s.GetAllKeys()
 	if err != nil {
@@ -127,17 +166,26 @@ func (s *CloudflareSession) Size() (int, []kv.Key) {
 }
 
 func (s *CloudflareSession) WriteEntry(entry models.Entry) error {
-	return s.WriteEntries([]models.Entry{entry})
+	unsuccessful, err := s.WriteEntries([]models.Entry{entry})
+	if err != nil {
+		return err
+	}
+	if len(unsuccessful) > 0 {
+		return fmt.Errorf("cloudflare did not store %q", entry.Name)
+	}
+	return nil
 }
 
-func (s *CloudflareSession) WriteEntries(entries []models.Entry) error {
+// WriteEntries writes entries in one bulk request and returns the keys
+// Cloudflare reported as not stored. Those keys should be retried.
+func (s *CloudflareSession) WriteEntries(entries []models.Entry) ([]string, error) {
 	if len(entries) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	kvs := entriesToBulkUpdateBodies(entries)
 
-	_, err := s.client.KV.Namespaces.BulkUpdate(
+	resp, err := s.client.KV.Namespaces.BulkUpdate(
 		context.Background(),
 		s.namespaceID,
 		kv.NamespaceBulkUpdateParams{
@@ -146,10 +194,13 @@ func (s *CloudflareSession) WriteEntries(entries []models.Entry) error {
 		},
 	)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if resp == nil {
+		return nil, nil
 	}
 
-	return nil
+	return resp.UnsuccessfulKeys, nil
 }
 
 func (s *CloudflareSession) DeleteKeyValue(key string) error {
@@ -168,12 +219,14 @@ func (s *CloudflareSession) DeleteKeyValue(key string) error {
 	return nil
 }
 
-func (s *CloudflareSession) DeleteKeyValues(keys []string) error {
+// DeleteKeyValues deletes keys in one bulk request and returns the keys
+// Cloudflare reported as not deleted. Those keys should be retried.
+func (s *CloudflareSession) DeleteKeyValues(keys []string) ([]string, error) {
 	if len(keys) == 0 {
-		return nil
+		return nil, nil
 	}
 
-	_, err := s.client.KV.Namespaces.BulkDelete(
+	resp, err := s.client.KV.Namespaces.BulkDelete(
 		context.Background(),
 		s.namespaceID,
 		kv.NamespaceBulkDeleteParams{
@@ -182,10 +235,13 @@ func (s *CloudflareSession) DeleteKeyValues(keys []string) error {
 		},
 	)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if resp == nil {
+		return nil, nil
 	}
 
-	return nil
+	return resp.UnsuccessfulKeys, nil
 }
 
 func entriesToBulkUpdateBodies(entries []models.Entry) []kv.NamespaceBulkUpdateParamsBody {
@@ -246,6 +302,10 @@ func normalizeBulkGetResponse(resp *kv.NamespaceBulkGetResponse) ([]models.Entry
 
 	entries := make([]models.Entry, 0, len(envelope.Values))
 	for key, item := range envelope.Values {
+		// keys that do not exist come back as null
+		if item == nil {
+			continue
+		}
 		entries = append(entries, models.Entry{
 			Name:     key,
 			Value:    item.Value,
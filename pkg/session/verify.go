@@ -0,0 +1,121 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"cdnmanager/pkg/config"
+
+	cloudflare "github.com/cloudflare/cloudflare-go/v6"
+	"github.com/cloudflare/cloudflare-go/v6/accounts"
+	"github.com/cloudflare/cloudflare-go/v6/kv"
+	"github.com/cloudflare/cloudflare-go/v6/option"
+	"github.com/cloudflare/cloudflare-go/v6/user"
+)
+
+var (
+	ErrInvalidToken         = errors.New("api token is invalid")
+	ErrInactiveToken        = errors.New("api token is not active")
+	ErrAccountNotFound      = errors.New("account not found or not accessible with this token")
+	ErrNamespaceNotFound    = errors.New("namespace not found in this account")
+	ErrNamespaceNotReadable = errors.New("token cannot read keys in this namespace")
+	ErrNamespaceNotWritable = errors.New("token cannot write to this namespace")
+)
+
+const verifyTimeout = 30 * time.Second
+
+// permissionProbeKey is deleted from the namespace to check write access.
+// Deleting a key that does not exist succeeds without changing anything.
+const permissionProbeKey = "cdnmanager-permission-check-4f1e0c2a"
+
+// VerifyCredentials checks that cfg can be used against Cloudflare: the token
+// is valid and active, the account exists, and the namespace exists and is
+// readable and writable. Failures wrap one of the Err* values above.
+func VerifyCredentials(cfg config.Config) error {
+	if !cfg.IsComplete() {
+		return fmt.Errorf("config is incomplete")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), verifyTimeout)
+	defer cancel()
+
+	client := cloudflare.NewClient(
+		option.WithAPIToken(cfg.CloudflareAPIToken),
+	)
+
+	if err := verifyToken(ctx, client, cfg.AccountID); err != nil {
+		return err
+	}
+
+	_, err := client.Accounts.Get(ctx, accounts.AccountGetParams{
+		AccountID: cloudflare.F(cfg.AccountID),
+	})
+	if err != nil {
+		if isStatus(err, http.StatusNotFound, http.StatusForbidden) {
+			return fmt.Errorf("%w: %s", ErrAccountNotFound, cfg.AccountID)
+		}
+		return fmt.Errorf("get account %s: %w", cfg.AccountID, err)
+	}
+
+	_, err = client.KV.Namespaces.Get(ctx, cfg.NamespaceID, kv.NamespaceGetParams{
+		AccountID: cloudflare.F(cfg.AccountID),
+	})
+	if err != nil {
+		if isStatus(err, http.StatusNotFound) {
+			return fmt.Errorf("%w: %s", ErrNamespaceNotFound, cfg.NamespaceID)
+		}
+		if isStatus(err, http.StatusForbidden) {
+			return fmt.Errorf("%w: %v", ErrNamespaceNotReadable, err)
+		}
+		return fmt.Errorf("get namespace %s: %w", cfg.NamespaceID, err)
+	}
+
+	_, err = client.KV.Namespaces.Keys.List(ctx, cfg.NamespaceID, kv.NamespaceKeyListParams{
+		AccountID: cloudflare.F(cfg.AccountID),
+		Limit:     cloudflare.F(10.0),
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNamespaceNotReadable, err)
+	}
+
+	_, err = client.KV.Namespaces.BulkDelete(ctx, cfg.NamespaceID, kv.NamespaceBulkDeleteParams{
+		AccountID: cloudflare.F(cfg.AccountID),
+		Body:      []string{permissionProbeKey},
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNamespaceNotWritable, err)
+	}
+
+	return nil
+}
+
+// verifyToken accepts both user tokens and account-owned tokens, which are
+// verified through different endpoints.
+func verifyToken(ctx context.Context, client *cloudflare.Client, accountID string) error {
+	var status string
+
+	userToken, err := client.User.Tokens.Verify(ctx)
+	if err == nil {
+		status = string(userToken.Status)
+	} else {
+		accountToken, accountErr := client.Accounts.Tokens.Verify(ctx, accounts.TokenVerifyParams{
+			AccountID: cloudflare.F(accountID),
+		})
+		if accountErr != nil {
+			if isStatus(accountErr, http.StatusUnauthorized, http.StatusForbidden, http.StatusBadRequest) {
+				return ErrInvalidToken
+			}
+			return fmt.Errorf("verify api token: %w", accountErr)
+		}
+		status = string(accountToken.Status)
+	}
+
+	if status != string(user.TokenVerifyResponseStatusActive) {
+		return fmt.Errorf("%w: status %q", ErrInactiveToken, status)
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"cdnmanager/pkg/config"
+)
+
+var uuidV4Pattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-4[0-9a-fA-F]{3}-[89abAB][0-9a-fA-F]{3}-[0-9a-fA-F]{12}$`)
+
+func ValidateKey(key string, policy config.KeyPolicy) error {
+	if key == "" {
+		return fmt.Errorf("key cannot be empty")
+	}
+	if key == "." || key == ".." {
+		return fmt.Errorf("key %q is not allowed by cloudflare", key)
+	}
+
+	maxBytes := policy.EffectiveMaxBytes()
+	if len(key) > maxBytes {
+		return fmt.Errorf("key %q is %d bytes, exceeding the %d byte limit", key, len(key), maxBytes)
+	}
+
+	rest := key
+	if policy.Prefix != "" {
+		if !strings.HasPrefix(key, policy.Prefix) {
+			return fmt.Errorf("key %q must start with %q", key, policy.Prefix)
+		}
+		rest = strings.TrimPrefix(key, policy.Prefix)
+	}
+
+	if policy.RequireUUIDv4 && !uuidV4Pattern.MatchString(rest) {
+		if policy.Prefix != "" {
+			return fmt.Errorf("key %q must be %q followed by a version 4 UUID", key, policy.Prefix)
+		}
+		return fmt.Errorf("key %q must be a version 4 UUID", key)
+	}
+
+	if policy.Pattern != "" {
+		pattern, err := regexp.Compile(policy.Pattern)
+		if err != nil {
+			return fmt.Errorf("key policy pattern %q: %w", policy.Pattern, err)
+		}
+		if !pattern.MatchString(key) {
+			return fmt.Errorf("key %q does not match pattern %q", key, policy.Pattern)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,202 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"cdnmanager/pkg/config"
+	"cdnmanager/pkg/models"
+)
+
+// MaxMetadataBytes is the Cloudflare Workers KV limit on serialized metadata.
+const MaxMetadataBytes = 1024
+
+const MaxDescriptionLength = 512
+
+var md5Pattern = regexp.MustCompile(`^[0-9a-fA-F]{32}$`)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+type FieldErrors []FieldError
+
+func (e FieldErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		messages = append(messages, fieldErr.Error())
+	}
+	return "invalid metadata: " + strings.Join(messages, "; ")
+}
+
+// ParseMetadataJSON parses raw metadata. Keys that are neither built-in
+// metadata fields nor configured custom fields are kept in Extra unchanged.
+// String values of number and boolean custom fields are converted so that
+// form and CSV input can be passed through unchanged.
+func ParseMetadataJSON(raw string, customFields []config.CustomField) (models.Metadata, error) {
+	meta, err := models.MetadataFromJSONString(raw)
+	if err != nil {
+		return models.Metadata{}, fmt.Errorf("parse metadata: %w", err)
+	}
+
+	definitions := customFieldsByName(customFields)
+
+	errs := make(FieldErrors, 0)
+	for field, value := range meta.Extra {
+		definition, ok := definitions[field]
+		if !ok {
+			continue
+		}
+
+		converted, err := coerceCustomValue(definition, value)
+		if err != nil {
+			errs = append(errs, FieldError{Field: field, Message: err.Error()})
+			continue
+		}
+		if converted == "" {
+			delete(meta.Extra, field)
+			continue
+		}
+		meta.Extra[field] = converted
+	}
+
+	if len(errs) > 0 {
+		return models.Metadata{}, errs
+	}
+
+	return meta, nil
+}
+
+func ValidateMetadata(meta models.Metadata, customFields []config.CustomField) error {
+	errs := make(FieldErrors, 0)
+
+	require := func(field, value string) {
+		if strings.TrimSpace(value) == "" {
+			errs = append(errs, FieldError{Field: field, Message: "is required"})
+		}
+	}
+
+	require("name", meta.Name)
+	require("mimetype", meta.MimeType)
+
+	if !meta.External {
+		require("location", meta.Location)
+		require("cloud_storage_id", meta.CloudStorageID)
+		require("md5Checksum", meta.MD5Checksum)
+	}
+
+	for _, field := range customFields {
+		value, ok := meta.Extra[field.Name]
+		if !ok || value == nil || value == "" {
+			if field.Required {
+				errs = append(errs, FieldError{Field: field.Name, Message: "is required"})
+			}
+			continue
+		}
+
+		if !customValueHasType(field, value) {
+			errs = append(errs, FieldError{Field: field.Name, Message: "must be a " + field.Type})
+		}
+	}
+
+	if meta.MimeType != "" && !IsKnownMimeType(meta.MimeType) {
+		errs = append(errs, FieldError{
+			Field:   "mimetype",
+			Message: fmt.Sprintf("%q is not a recognised mimetype", meta.MimeType),
+		})
+	}
+
+	if meta.MD5Checksum != "" && !md5Pattern.MatchString(meta.MD5Checksum) {
+		errs = append(errs, FieldError{
+			Field:   "md5Checksum",
+			Message: "must be 32 hexadecimal characters",
+		})
+	}
+
+	if length := utf8.RuneCountInString(meta.Description); length > MaxDescriptionLength {
+		errs = append(errs, FieldError{
+			Field:   "description",
+			Message: fmt.Sprintf("is %d characters, exceeding the %d character limit", length, MaxDescriptionLength),
+		})
+	}
+
+	metadataJSON, err := meta.ToJSONString()
+	if err != nil {
+		return err
+	}
+	if len(metadataJSON) > MaxMetadataBytes {
+		errs = append(errs, FieldError{
+			Field:   "metadata",
+			Message: fmt.Sprintf("serializes to %d bytes, exceeding the %d byte limit", len(metadataJSON), MaxMetadataBytes),
+		})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func customFieldsByName(customFields []config.CustomField) map[string]config.CustomField {
+	definitions := make(map[string]config.CustomField, len(customFields))
+	for _, field := range customFields {
+		definitions[field.Name] = field
+	}
+	return definitions
+}
+
+func coerceCustomValue(field config.CustomField, value any) (any, error) {
+	text, ok := value.(string)
+	if !ok || field.Type == config.CustomFieldString {
+		if !customValueHasType(field, value) {
+			return nil, fmt.Errorf("must be a %s", field.Type)
+		}
+		return value, nil
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", nil
+	}
+
+	switch field.Type {
+	case config.CustomFieldNumber:
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be a number")
+		}
+		return number, nil
+	case config.CustomFieldBoolean:
+		boolean, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, fmt.Errorf("must be true or false")
+		}
+		return boolean, nil
+	}
+
+	return value, nil
+}
+
+func customValueHasType(field config.CustomField, value any) bool {
+	switch field.Type {
+	case config.CustomFieldString:
+		_, ok := value.(string)
+		return ok
+	case config.CustomFieldNumber:
+		_, ok := value.(float64)
+		return ok
+	case config.CustomFieldBoolean:
+		_, ok := value.(bool)
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package validation
+
+import (
+	"mime"
+	"sort"
+	"strings"
+)
+
+var knownMimeTypes = []string{
+	"application/epub+zip",
+	"application/gzip",
+	"application/json",
+	"application/msword",
+	"application/octet-stream",
+	"application/pdf",
+	"application/rtf",
+	"application/vnd.ms-excel",
+	"application/vnd.ms-powerpoint",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"application/vnd.google-apps.document",
+	"application/vnd.google-apps.folder",
+	"application/vnd.google-apps.presentation",
+	"application/vnd.google-apps.spreadsheet",
+	"application/x-7z-compressed",
+	"application/x-tar",
+	"application/xml",
+	"application/zip",
+	"audio/aac",
+	"audio/flac",
+	"audio/mp4",
+	"audio/mpeg",
+	"audio/ogg",
+	"audio/wav",
+	"audio/webm",
+	"font/otf",
+	"font/ttf",
+	"font/woff",
+	"font/woff2",
+	"image/avif",
+	"image/bmp",
+	"image/gif",
+	"image/heic",
+	"image/jpeg",
+	"image/png",
+	"image/svg+xml",
+	"image/tiff",
+	"image/webp",
+	"image/x-icon",
+	"text/calendar",
+	"text/css",
+	"text/csv",
+	"text/html",
+	"text/javascript",
+	"text/markdown",
+	"text/plain",
+	"text/xml",
+	"video/mp4",
+	"video/mpeg",
+	"video/ogg",
+	"video/quicktime",
+	"video/webm",
+	"video/x-msvideo",
+}
+
+var mimeTypeRegistry = func() map[string]struct{} {
+	registry := make(map[string]struct{}, len(knownMimeTypes))
+	for _, mimeType := range knownMimeTypes {
+		registry[mimeType] = struct{}{}
+	}
+	return registry
+}()
+
+// NormalizeMimeType lowercases a media type and strips any parameters such as
+// charset.
+func NormalizeMimeType(mimeType string) string {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(mimeType))
+	}
+	return mediaType
+}
+
+func IsKnownMimeType(mimeType string) bool {
+	_, ok := mimeTypeRegistry[NormalizeMimeType(mimeType)]
+	return ok
+}
+
+func KnownMimeTypes() []string {
+	mimeTypes := append([]string(nil), knownMimeTypes...)
+	sort.Strings(mimeTypes)
+	return mimeTypes
+}